@@ -0,0 +1,339 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hit is a redirect by a record.
+type Hit struct {
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the record, the pattern for a pattern record.
+	Name     string     `json:"name"`
+	Time     time.Time  `json:"time"`
+	Referrer string     `json:"referrer"`
+	Agent    AgentClass `json:"agent"`
+}
+
+// ReferrerDirect is the referrer of the hits without a referrer.
+const ReferrerDirect = "direct"
+
+// NewHit builds a hit keeping only the host of the referrer.
+func NewHit(namespace, name string, t time.Time, referrer, userAgent string) *Hit {
+	return &Hit{
+		Namespace: namespace,
+		Name:      name,
+		Time:      t,
+		Referrer:  referrerHost(referrer),
+		Agent:     ClassifyAgent(userAgent),
+	}
+}
+
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ReferrerDirect
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return ReferrerDirect
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// AgentClass is a coarse class of the user-agent.
+type AgentClass string
+
+const (
+	AgentBrowser AgentClass = "browser"
+	AgentMobile  AgentClass = "mobile"
+	AgentBot     AgentClass = "bot"
+	AgentCLI     AgentClass = "cli"
+	AgentUnknown AgentClass = "unknown"
+)
+
+// ClassifyAgent classifies the User-Agent header.
+func ClassifyAgent(userAgent string) AgentClass {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return AgentUnknown
+	case containsAny(ua, "bot", "crawler", "spider", "slurp", "preview", "facebookexternalhit"):
+		return AgentBot
+	case containsAny(ua, "curl/", "wget/", "httpie/", "python-requests/", "go-http-client/", "okhttp/"):
+		return AgentCLI
+	case containsAny(ua, "mobile", "android", "iphone", "ipad"):
+		return AgentMobile
+	case strings.HasPrefix(ua, "mozilla/"):
+		return AgentBrowser
+	default:
+		return AgentUnknown
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, x := range substrs {
+		if strings.Contains(s, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats is the summary of the hits of a record.
+type Stats struct {
+	Namespace string             `json:"namespace,omitempty"`
+	Name      string             `json:"name"`
+	Total     int                `json:"total"`
+	FirstHit  *time.Time         `json:"first_hit,omitempty"`
+	LastHit   *time.Time         `json:"last_hit,omitempty"`
+	Referrers map[string]int     `json:"referrers,omitempty"`
+	Agents    map[AgentClass]int `json:"agents,omitempty"`
+}
+
+func (s *Stats) add(hit *Hit) {
+	s.Total++
+	if s.FirstHit == nil || hit.Time.Before(*s.FirstHit) {
+		t := hit.Time
+		s.FirstHit = &t
+	}
+	if s.LastHit == nil || hit.Time.After(*s.LastHit) {
+		t := hit.Time
+		s.LastHit = &t
+	}
+	if s.Referrers == nil {
+		s.Referrers = map[string]int{}
+	}
+	s.Referrers[hit.Referrer]++
+	if s.Agents == nil {
+		s.Agents = map[AgentClass]int{}
+	}
+	s.Agents[hit.Agent]++
+}
+
+func (s *Stats) clone() *Stats {
+	c := *s
+	if s.FirstHit != nil {
+		t := *s.FirstHit
+		c.FirstHit = &t
+	}
+	if s.LastHit != nil {
+		t := *s.LastHit
+		c.LastHit = &t
+	}
+	if s.Referrers != nil {
+		c.Referrers = make(map[string]int, len(s.Referrers))
+		for k, v := range s.Referrers {
+			c.Referrers[k] = v
+		}
+	}
+	if s.Agents != nil {
+		c.Agents = make(map[AgentClass]int, len(s.Agents))
+		for k, v := range s.Agents {
+			c.Agents[k] = v
+		}
+	}
+	return &c
+}
+
+// HitStore persists the hits.
+type HitStore interface {
+	Add(ctx context.Context, hits []*Hit) error
+	// Stats returns the summary of the hits of the record, zero if no hits.
+	Stats(ctx context.Context, namespace, name string) (*Stats, error)
+}
+
+// hitStats aggregates the hits in memory by recordKey.
+type hitStats struct {
+	stats map[string]*Stats
+	mux   sync.RWMutex
+}
+
+func newHitStats() *hitStats {
+	return &hitStats{
+		stats: map[string]*Stats{},
+	}
+}
+
+func (h *hitStats) add(hits []*Hit) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	for _, hit := range hits {
+		key := recordKey(hit.Namespace, hit.Name)
+		s, ok := h.stats[key]
+		if !ok {
+			s = &Stats{
+				Namespace: hit.Namespace,
+				Name:      hit.Name,
+			}
+			h.stats[key] = s
+		}
+		s.add(hit)
+	}
+}
+
+func (h *hitStats) get(namespace, name string) *Stats {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	if s, ok := h.stats[recordKey(namespace, name)]; ok {
+		return s.clone()
+	}
+	return &Stats{
+		Namespace: namespace,
+		Name:      name,
+	}
+}
+
+// NewHitMemory returns a HitStore that does not persist the hits.
+func NewHitMemory() HitStore {
+	return &hitMemory{
+		stats: newHitStats(),
+	}
+}
+
+type hitMemory struct {
+	stats *hitStats
+}
+
+func (m *hitMemory) Add(_ context.Context, hits []*Hit) error {
+	m.stats.add(hits)
+	return nil
+}
+
+func (m *hitMemory) Stats(_ context.Context, namespace, name string) (*Stats, error) {
+	return m.stats.get(namespace, name), nil
+}
+
+// NewHitFile returns a HitStore on the JSON Lines file, aggregating the hits in it on open.
+func NewHitFile(filename string) (HitStore, error) {
+	f := &hitFile{
+		filename: filename,
+		stats:    newHitStats(),
+	}
+	lines, err := readJSONLines(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w, read hits %v", ErrReadDatabase, err)
+	}
+	hits := make([]*Hit, 0, len(lines))
+	for _, line := range lines {
+		var h Hit
+		if err := json.Unmarshal(line, &h); err != nil {
+			return nil, fmt.Errorf("%w, unmarshal hits", ErrReadDatabase)
+		}
+		hits = append(hits, &h)
+	}
+	f.stats.add(hits)
+	return f, nil
+}
+
+type hitFile struct {
+	filename string
+	stats    *hitStats
+	mux      sync.Mutex
+}
+
+func (f *hitFile) Add(_ context.Context, hits []*Hit) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if err := appendJSONLines(f.filename, hits); err != nil {
+		return fmt.Errorf("%w, write hits %v", ErrWriteDatabase, err)
+	}
+	f.stats.add(hits)
+	return nil
+}
+
+func (f *hitFile) Stats(_ context.Context, namespace, name string) (*Stats, error) {
+	return f.stats.get(namespace, name), nil
+}
+
+const (
+	// DefaultHitBufferSize is the number of hits HitRecorder buffers by default.
+	DefaultHitBufferSize = 1024
+	// DefaultHitFlushInterval is how often HitRecorder writes the buffered hits by default.
+	DefaultHitFlushInterval = time.Second
+)
+
+// HitRecorder writes the hits to the store asynchronously.
+type HitRecorder struct {
+	store    HitStore
+	hits     chan *Hit
+	interval time.Duration
+	dropped  atomic.Int64
+}
+
+// NewHitRecorder returns a HitRecorder buffering bufferSize hits, DefaultHitBufferSize if less than 1.
+func NewHitRecorder(store HitStore, bufferSize int, interval time.Duration) *HitRecorder {
+	if bufferSize < 1 {
+		bufferSize = DefaultHitBufferSize
+	}
+	return &HitRecorder{
+		store:    store,
+		hits:     make(chan *Hit, bufferSize),
+		interval: interval,
+	}
+}
+
+// Record buffers the hit without blocking, dropping it if the buffer is full.
+func (r *HitRecorder) Record(hit *Hit) {
+	select {
+	case r.hits <- hit:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of the hits dropped because the buffer was full.
+func (r *HitRecorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Stats returns the summary of the written hits of the record.
+func (r *HitRecorder) Stats(ctx context.Context, namespace, name string) (*Stats, error) {
+	return r.store.Stats(ctx, namespace, name)
+}
+
+// Run writes the buffered hits every interval or when the buffer is full until ctx is canceled.
+func (r *HitRecorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]*Hit, 0, cap(r.hits))
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.store.Add(ctx, batch); err != nil {
+			slog.Error("write hits", slog.Int("hits", len(batch)), slog.Any("error", err))
+		}
+		batch = make([]*Hit, 0, cap(r.hits))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case hit := <-r.hits:
+					batch = append(batch, hit)
+				default:
+					flush(context.Background())
+					return
+				}
+			}
+		case hit := <-r.hits:
+			batch = append(batch, hit)
+			if len(batch) >= cap(r.hits) {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestNewHit(t *testing.T) {
+	for _, tc := range []struct {
+		referrer  string
+		userAgent string
+		want      *api.Hit
+	}{
+		{
+			want: &api.Hit{Referrer: api.ReferrerDirect, Agent: api.AgentUnknown},
+		},
+		{
+			referrer:  "https://WWW.Example.com:8443/path?q=1",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			want:      &api.Hit{Referrer: "www.example.com", Agent: api.AgentBrowser},
+		},
+		{
+			referrer:  "not a url",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148",
+			want:      &api.Hit{Referrer: api.ReferrerDirect, Agent: api.AgentMobile},
+		},
+		{
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want:      &api.Hit{Referrer: api.ReferrerDirect, Agent: api.AgentBot},
+		},
+		{
+			userAgent: "curl/8.4.0",
+			want:      &api.Hit{Referrer: api.ReferrerDirect, Agent: api.AgentCLI},
+		},
+		{
+			userAgent: "something",
+			want:      &api.Hit{Referrer: api.ReferrerDirect, Agent: api.AgentUnknown},
+		},
+	} {
+		t.Run(tc.userAgent, func(t *testing.T) {
+			got := api.NewHit(api.DefaultNamespace, "", time.Time{}, tc.referrer, tc.userAgent)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHitRecorder(t *testing.T) {
+	var (
+		store       = api.NewHitMemory()
+		recorder    = api.NewHitRecorder(store, 10, time.Hour)
+		base        = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+	go func() {
+		recorder.Run(ctx)
+		close(done)
+	}()
+
+	recorder.Record(api.NewHit(api.DefaultNamespace, "a", base.Add(time.Minute), "https://example.com/", "curl/8.4.0"))
+	recorder.Record(api.NewHit(api.DefaultNamespace, "a", base, "", "curl/8.4.0"))
+	recorder.Record(api.NewHit(api.DefaultNamespace, "b", base, "", ""))
+	// the rest of the buffer is written on cancel
+	cancel()
+	<-done
+
+	if n := recorder.Dropped(); n != 0 {
+		t.Errorf("want no dropped hits, got %d", n)
+	}
+	got, err := recorder.Stats(context.Background(), api.DefaultNamespace, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		first = base
+		last  = base.Add(time.Minute)
+	)
+	want := &api.Stats{
+		Name:      "a",
+		Total:     2,
+		FirstHit:  &first,
+		LastHit:   &last,
+		Referrers: map[string]int{"example.com": 1, api.ReferrerDirect: 1},
+		Agents:    map[api.AgentClass]int{api.AgentCLI: 2},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+	if got, _ := recorder.Stats(context.Background(), api.DefaultNamespace, "b"); got.Total != 1 {
+		t.Errorf("b: want 1 hit, got %d", got.Total)
+	}
+	if got, _ := recorder.Stats(context.Background(), api.DefaultNamespace, "c"); got.Total != 0 {
+		t.Errorf("c: want no hits, got %d", got.Total)
+	}
+}
+
+func TestHitRecorderDrop(t *testing.T) {
+	recorder := api.NewHitRecorder(api.NewHitMemory(), 1, time.Hour)
+	// not running, so the second hit does not fit in the buffer
+	recorder.Record(api.NewHit(api.DefaultNamespace, "a", time.Now(), "", ""))
+	recorder.Record(api.NewHit(api.DefaultNamespace, "a", time.Now(), "", ""))
+	if n := recorder.Dropped(); n != 1 {
+		t.Errorf("want 1 dropped hit, got %d", n)
+	}
+
+	// the default buffer instead of none
+	recorder = api.NewHitRecorder(api.NewHitMemory(), 0, time.Hour)
+	recorder.Record(api.NewHit(api.DefaultNamespace, "a", time.Now(), "", ""))
+	if n := recorder.Dropped(); n != 0 {
+		t.Errorf("buffer 0: want no dropped hits, got %d", n)
+	}
+}
+
+func TestHitFile(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		filename = filepath.Join(t.TempDir(), "hits")
+		now      = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	store, err := api.NewHitFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(ctx, []*api.Hit{
+		api.NewHit(api.DefaultNamespace, "a", now, "", "curl/8.4.0"),
+		api.NewHit(api.DefaultNamespace, "a", now, "", "curl/8.4.0"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// a partial line left by a crash
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"name":"a","ti`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	reopened, err := api.NewHitFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Add(ctx, []*api.Hit{api.NewHit(api.DefaultNamespace, "a", now, "", "")}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Stats(ctx, api.DefaultNamespace, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 3 {
+		t.Errorf("want 3 hits, got %d", got.Total)
+	}
+	if _, err := api.NewHitFile(filename); err != nil {
+		t.Errorf("reopen after repair: %v", err)
+	}
+}
+
+func TestServerImplStats(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "gh/{org}", To: "https://github.com/{org}"},
+		&api.Record{Name: "docs", To: "https://example.com/docs"},
+	)
+	var (
+		ctx          = context.Background()
+		recorder     = api.NewHitRecorder(api.NewHitMemory(), 10, time.Hour)
+		rctx, cancel = context.WithCancel(ctx)
+		done         = make(chan struct{})
+	)
+	server.SetHitRecorder(recorder)
+	go func() {
+		recorder.Run(rctx)
+		close(done)
+	}()
+
+	for _, name := range []string{"gh/a", "gh/b", "docs", "unknown"} {
+		server.Redirect(ctx, &api.RedirectRequest{
+			Name:      name,
+			Referrer:  "https://example.com/",
+			UserAgent: "Mozilla/5.0",
+		})
+	}
+	cancel()
+	<-done
+
+	res, err := server.Stats(ctx, &api.StatsRequest{Name: "gh/{org}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stats.Total != 2 {
+		t.Errorf("want 2 hits of the pattern, got %d", res.Stats.Total)
+	}
+	if got := res.Stats.Agents[api.AgentBrowser]; got != 2 {
+		t.Errorf("want 2 browser hits, got %d", got)
+	}
+	if _, err := server.Stats(ctx, &api.StatsRequest{Name: "unknown"}); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Errorf("want ErrRecordNotFound, got %v", err)
+	}
+}
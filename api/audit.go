@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAuditMaxSize = 10 << 20
+	DefaultAuditBackups = 5
+)
+
+// AuditOp is the management operation of an audit entry.
+type AuditOp string
+
+const (
+	AuditOpPut      AuditOp = "put"
+	AuditOpDelete   AuditOp = "delete"
+	AuditOpRollback AuditOp = "rollback"
+	// AuditOpBatch is the batch call rejected before its operations are known.
+	AuditOpBatch AuditOp = "batch"
+)
+
+// AuditEntry is a call of a management operation changing the records.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Actor is the name of the token of the call, empty if the tokens are disabled.
+	Actor string `json:"actor,omitempty"`
+	// Remote is the address of the client of the call.
+	Remote    string  `json:"remote,omitempty"`
+	Op        AuditOp `json:"op"`
+	Namespace string  `json:"namespace,omitempty"`
+	Name      string  `json:"name"`
+	// Record is the record of the put.
+	Record *Record `json:"record,omitempty"`
+	// Revision is the revision of the rollback.
+	Revision int `json:"revision,omitempty"`
+	// Error is the error of the call, empty if the call succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditQuery selects the audit entries.
+type AuditQuery struct {
+	// Since selects the entries at or after the time, all if nil.
+	Since *time.Time `json:"since,omitempty"`
+	// Until selects the entries before the time, all if nil.
+	Until *time.Time `json:"until,omitempty"`
+	// Namespace and Name select the entries of the record, all if Name is empty.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Match reports whether the query selects the entry.
+func (q *AuditQuery) Match(e *AuditEntry) bool {
+	switch {
+	case q.Since != nil && e.Time.Before(*q.Since):
+		return false
+	case q.Until != nil && !e.Time.Before(*q.Until):
+		return false
+	case q.Name != "" && (e.Namespace != q.Namespace || e.Name != q.Name):
+		return false
+	default:
+		return true
+	}
+}
+
+// AuditLog is the append-only sink of the audit entries.
+type AuditLog interface {
+	Add(ctx context.Context, entry *AuditEntry) error
+	// Query returns the entries the query selects, oldest first.
+	Query(ctx context.Context, query *AuditQuery) ([]*AuditEntry, error)
+}
+
+type remoteAddrKey struct{}
+
+// withRemoteAddr returns the context of the request from the client address.
+func withRemoteAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, remoteAddrKey{}, addr)
+}
+
+func remoteAddrFromContext(ctx context.Context) string {
+	addr, _ := ctx.Value(remoteAddrKey{}).(string)
+	return addr
+}
+
+// NewAuditMemory returns an AuditLog that does not persist the entries.
+func NewAuditMemory() AuditLog {
+	return &auditMemory{}
+}
+
+type auditMemory struct {
+	entries []*AuditEntry
+	mux     sync.RWMutex
+}
+
+func (m *auditMemory) Add(_ context.Context, entry *AuditEntry) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *auditMemory) Query(_ context.Context, query *AuditQuery) ([]*AuditEntry, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	var es []*AuditEntry
+	for _, e := range m.entries {
+		if query.Match(e) {
+			es = append(es, e)
+		}
+	}
+	return es, nil
+}
+
+// NewAuditFile returns an AuditLog on the JSON Lines file rotated at maxSize bytes, never if zero.
+func NewAuditFile(filename string, maxSize int64, backups int) AuditLog {
+	return &auditFile{
+		filename: filename,
+		maxSize:  maxSize,
+		backups:  backups,
+	}
+}
+
+type auditFile struct {
+	filename string
+	maxSize  int64
+	backups  int
+	mux      sync.Mutex
+}
+
+func (f *auditFile) Add(_ context.Context, entry *AuditEntry) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("%w, marshal audit entry", ErrWriteDatabase)
+	}
+	if err := f.rotate(int64(len(b) + 1)); err != nil {
+		return fmt.Errorf("%w, rotate audit log %v", ErrWriteDatabase, err)
+	}
+	if err := appendJSONLines(f.filename, []json.RawMessage{b}); err != nil {
+		return fmt.Errorf("%w, write audit log %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
+
+// rotate moves the file to the first backup if appending n bytes would exceed maxSize.
+func (f *auditFile) rotate(n int64) error {
+	if f.maxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(f.filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case info.Size() == 0 || info.Size()+n <= f.maxSize:
+		return nil
+	}
+	if f.backups <= 0 {
+		return os.Remove(f.filename)
+	}
+	if err := os.Remove(f.backup(f.backups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := f.backups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(f.filename, f.backup(1))
+}
+
+func (f *auditFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.filename, i)
+}
+
+func (f *auditFile) Query(_ context.Context, query *AuditQuery) ([]*AuditEntry, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	filenames := []string{f.filename}
+	for i := 1; i <= f.backups; i++ {
+		filenames = append([]string{f.backup(i)}, filenames...)
+	}
+	var es []*AuditEntry
+	for _, filename := range filenames {
+		lines, err := readJSONLines(filename)
+		if err != nil {
+			return nil, fmt.Errorf("%w, read audit log %v", ErrReadDatabase, err)
+		}
+		for _, line := range lines {
+			var e AuditEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return nil, fmt.Errorf("%w, unmarshal audit log %s", ErrReadDatabase, filename)
+			}
+			if query.Match(&e) {
+				es = append(es, &e)
+			}
+		}
+	}
+	return es, nil
+}
@@ -0,0 +1,253 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+// readOnlyDatabaseFile fails all the writes.
+type readOnlyDatabaseFile struct {
+	api.DatabaseFile
+}
+
+func (f readOnlyDatabaseFile) Write(_ []*api.Record) error {
+	return fmt.Errorf("%w, read only", api.ErrWriteDatabase)
+}
+
+func TestServerImplAudit(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	if _, err := server.Put(ctx, &api.PutRequest{Record: &api.Record{Name: "x", To: "https://example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Put(ctx, &api.PutRequest{Record: &api.Record{Name: "y", To: "https://example.com", StatusCode: 200}}); !errors.Is(err, api.ErrInvalidRecord) {
+		t.Fatalf("want ErrInvalidRecord, got %v", err)
+	}
+	if _, err := server.Delete(ctx, &api.DeleteRequest{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := server.Audit(ctx, &api.AuditRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Entries) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(got.Entries))
+	}
+	for i, want := range []struct {
+		op    api.AuditOp
+		name  string
+		error bool
+	}{
+		{op: api.AuditOpPut, name: "x"},
+		{op: api.AuditOpPut, name: "y", error: true},
+		{op: api.AuditOpDelete, name: "x"},
+	} {
+		e := got.Entries[i]
+		if e.Op != want.op || e.Name != want.name || (e.Error != "") != want.error {
+			t.Errorf("entry %d: want %v, got %+v", i, want, e)
+		}
+	}
+
+	t.Run("name", func(t *testing.T) {
+		got, err := server.Audit(ctx, &api.AuditRequest{Name: "y"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Entries) != 1 {
+			t.Errorf("want 1 entry, got %d", len(got.Entries))
+		}
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		future := time.Now().Add(time.Hour)
+		got, err := server.Audit(ctx, &api.AuditRequest{Since: &future})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Entries) != 0 {
+			t.Errorf("want no entries, got %d", len(got.Entries))
+		}
+	})
+}
+
+func TestServerImplAuditWriteFailure(t *testing.T) {
+	_, dbFile := newDatabaseFile(t)
+	server := api.NewServerImpl(api.NewDatabaseImpl(readOnlyDatabaseFile{dbFile}))
+	ctx := context.Background()
+	if _, err := server.Put(ctx, &api.PutRequest{Record: &api.Record{Name: "x", To: "https://example.com"}}); !errors.Is(err, api.ErrWriteDatabase) {
+		t.Fatalf("want ErrWriteDatabase, got %v", err)
+	}
+	got, err := server.Audit(ctx, &api.AuditRequest{Name: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Entries) != 1 || !strings.Contains(got.Entries[0].Error, api.ErrWriteDatabase.Error()) {
+		t.Errorf("want the entry of WriteDatabase, got %v", got.Entries)
+	}
+}
+
+func TestClientImplAudit(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "team", Scope: api.ScopeReadWrite, Hash: api.HashToken("team-token"), Namespaces: []string{"team"}},
+		{Name: "admin", Scope: api.ScopeReadWrite, Hash: api.HashToken("admin-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/put", tokens.Require(api.ScopeReadWrite, api.API(server.Put)))
+	mux.HandleFunc("/audit", tokens.Require(api.ScopeReadWrite, api.API(server.Audit)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	newClient := func(token string) api.Client {
+		return api.NewClientImpl(ts.URL, &http.Client{
+			Transport: api.NewTokenTransport(token, nil),
+		})
+	}
+	var (
+		ctx   = context.Background()
+		team  = newClient("team-token").WithNamespace("team")
+		admin = newClient("admin-token")
+	)
+	if _, err := team.Put(ctx, &api.Record{Name: "x", To: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.Put(ctx, &api.Record{Name: "x", To: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := team.Audit(ctx, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Actor != "team" || got[0].Remote == "" {
+		t.Errorf("want 1 entry of team with the remote address, got %v", got)
+	}
+	got, err = admin.Audit(ctx, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("want 2 entries, got %d", len(got))
+	}
+	if _, err := team.WithNamespace(api.DefaultNamespace).Audit(ctx, nil, nil, "x"); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("want ErrForbidden, got %v", err)
+	}
+}
+
+func TestAuditFile(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		dir      = t.TempDir()
+		filename = filepath.Join(dir, "audit.jsonl")
+		log      = api.NewAuditFile(filename, 200, 2)
+		start    = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	for i := 0; i < 10; i++ {
+		if err := log.Add(ctx, &api.AuditEntry{
+			Time: start.Add(time.Duration(i) * time.Minute),
+			Op:   api.AuditOpDelete,
+			Name: fmt.Sprintf("name-%d", i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{filename, filename + ".1", filename + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s: want at most 200 bytes, got %d", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(filename + ".3"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want no third backup, got %v", err)
+	}
+
+	got, err := log.Query(ctx, &api.AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[len(got)-1].Name != "name-9" {
+		t.Fatalf("want the entries up to name-9, got %v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if !got[i-1].Time.Before(got[i].Time) {
+			t.Errorf("want the entries oldest first, got %v then %v", got[i-1].Time, got[i].Time)
+		}
+	}
+
+	since, until := start.Add(8*time.Minute), start.Add(9*time.Minute)
+	got, err = log.Query(ctx, &api.AuditQuery{Since: &since, Until: &until})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "name-8" {
+		t.Errorf("want name-8, got %v", got)
+	}
+}
+
+func TestAuditFilePartialLine(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		filename = filepath.Join(t.TempDir(), "audit.jsonl")
+		log      = api.NewAuditFile(filename, 0, 0)
+	)
+	if err := log.Add(ctx, &api.AuditEntry{Op: api.AuditOpPut, Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	// a partial line left by a crash
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"op":"del`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	before, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := log.Query(ctx, &api.AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("want 1 entry, got %d", len(got))
+	}
+	after, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("want the file unchanged by the query, got %q", after)
+	}
+
+	if err := log.Add(ctx, &api.AuditEntry{Op: api.AuditOpDelete, Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = log.Query(ctx, &api.AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Op != api.AuditOpDelete {
+		t.Errorf("want the put and the delete, got %v", got)
+	}
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnauthorized = errors.New("Unauthorized")
+	ErrForbidden    = errors.New("Forbidden")
+	ErrInvalidToken = errors.New("InvalidToken")
+)
+
+// Scope is what a token allows.
+type Scope string
+
+const (
+	// ScopeReadOnly allows the routes reading records.
+	ScopeReadOnly Scope = "ro"
+	// ScopeReadWrite allows all the management routes.
+	ScopeReadWrite Scope = "rw"
+)
+
+// Allows reports whether the scope covers the required scope.
+func (s Scope) Allows(required Scope) bool {
+	switch s {
+	case ScopeReadWrite:
+		return true
+	case ScopeReadOnly:
+		return required == ScopeReadOnly
+	default:
+		return false
+	}
+}
+
+// TokenEntry is the hash of a token in the token file.
+type TokenEntry struct {
+	// Name identifies the token in the logs.
+	Name  string `json:"name"`
+	Scope Scope  `json:"scope"`
+	// Hash is the output of HashToken.
+	Hash string `json:"hash"`
+	// Namespaces limits the token to the records of the namespaces, all the namespaces if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// AllowsNamespace reports whether the token may access the records of the namespace.
+func (e *TokenEntry) AllowsNamespace(namespace string) bool {
+	return len(e.Namespaces) == 0 || slices.Contains(e.Namespaces, namespace)
+}
+
+type tokenEntryKey struct{}
+
+// TokenEntryFromContext returns the entry of the token authenticated by Tokens.Require, nil if none.
+func TokenEntryFromContext(ctx context.Context) *TokenEntry {
+	e, _ := ctx.Value(tokenEntryKey{}).(*TokenEntry)
+	return e
+}
+
+// authorizeNamespace checks that the token of the request, if any, may access the namespace.
+func authorizeNamespace(ctx context.Context, namespace string) error {
+	if e := TokenEntryFromContext(ctx); e != nil && !e.AllowsNamespace(namespace) {
+		return fmt.Errorf("%w, %s cannot access namespace %s", ErrForbidden, e.Name, namespace)
+	}
+	return nil
+}
+
+// authorizeAllNamespaces checks that the token of the request is not limited to some namespaces.
+func authorizeAllNamespaces(ctx context.Context) error {
+	if e := TokenEntryFromContext(ctx); e != nil && len(e.Namespaces) > 0 {
+		return fmt.Errorf("%w, %s is limited to namespaces %v", ErrForbidden, e.Name, e.Namespaces)
+	}
+	return nil
+}
+
+const tokenHashPrefix = "sha256:"
+
+// HashToken returns the hash of the token to be stored in the token file.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return tokenHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// GenerateToken returns a new random token.
+func GenerateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Tokens authenticates the requests to the management API.
+type Tokens struct {
+	entries []*TokenEntry
+	audit   AuditLog
+}
+
+// NewTokens validates the token entries.
+func NewTokens(entries []*TokenEntry) (*Tokens, error) {
+	for _, e := range entries {
+		if e.Scope != ScopeReadOnly && e.Scope != ScopeReadWrite {
+			return nil, fmt.Errorf("%w, %s: scope %s is not one of ro and rw", ErrInvalidToken, e.Name, e.Scope)
+		}
+		if !strings.HasPrefix(e.Hash, tokenHashPrefix) {
+			return nil, fmt.Errorf("%w, %s: hash must start with %s", ErrInvalidToken, e.Name, tokenHashPrefix)
+		}
+		for _, ns := range e.Namespaces {
+			if err := ValidateNamespace(ns); err != nil {
+				return nil, fmt.Errorf("%w, %s: %v", ErrInvalidToken, e.Name, err)
+			}
+		}
+	}
+	return &Tokens{
+		entries: entries,
+	}, nil
+}
+
+// NewTokenFile reads the token entries from the JSON file.
+func NewTokenFile(filename string) (*Tokens, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w, read tokens %v", ErrInvalidToken, err)
+	}
+	var entries []*TokenEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("%w, unmarshal tokens %v", ErrInvalidToken, err)
+	}
+	return NewTokens(entries)
+}
+
+// Authenticate returns the entry of the token.
+func (t *Tokens) Authenticate(token string) (*TokenEntry, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%w, no token", ErrUnauthorized)
+	}
+	hash := []byte(HashToken(token))
+	for _, e := range t.entries {
+		if subtle.ConstantTimeCompare(hash, []byte(e.Hash)) == 1 {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w, unknown token", ErrUnauthorized)
+}
+
+// Authorize checks that the token has the scope.
+func (t *Tokens) Authorize(token string, scope Scope) (*TokenEntry, error) {
+	e, err := t.Authenticate(token)
+	if err != nil {
+		return nil, err
+	}
+	if !e.Scope.Allows(scope) {
+		return e, fmt.Errorf("%w, %s requires %s", ErrForbidden, e.Name, scope)
+	}
+	return e, nil
+}
+
+// SetAuditLog makes Require audit the rejected calls of the routes changing the records.
+func (t *Tokens) SetAuditLog(log AuditLog) {
+	t.audit = log
+}
+
+// Require rejects the requests without a bearer token of the scope, see TokenEntryFromContext.
+func (t *Tokens) Require(scope Scope, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		e, err := t.Authorize(token, scope)
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="redirect-store"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			slog.Info("auth", slog.String("url", r.URL.String()), slog.Any("error", err))
+			t.auditRejected(r, e, err)
+		case errors.Is(err, ErrForbidden):
+			w.WriteHeader(http.StatusForbidden)
+			slog.Info("auth", slog.String("url", r.URL.String()), slog.Any("error", err))
+			t.auditRejected(r, e, err)
+		default:
+			slog.Info("auth", slog.String("url", r.URL.String()), slog.String("token", e.Name))
+			h(w, r.WithContext(context.WithValue(r.Context(), tokenEntryKey{}, e)))
+		}
+	}
+}
+
+// auditRejected appends the rejected call to the audit log if the route changes the records.
+func (t *Tokens) auditRejected(r *http.Request, e *TokenEntry, err error) {
+	op, ok := auditedRoutes[r.URL.Path]
+	if t.audit == nil || !ok {
+		return
+	}
+	entry := &AuditEntry{
+		Time:   time.Now(),
+		Remote: r.RemoteAddr,
+		Op:     op,
+		Error:  err.Error(),
+	}
+	if e != nil {
+		entry.Actor = e.Name
+	}
+	if err := t.audit.Add(r.Context(), entry); err != nil {
+		slog.Error("add audit entry", slog.String("op", string(op)), slog.Any("error", err))
+	}
+}
+
+// NewTokenTransport returns a RoundTripper adding the bearer token to the requests of base.
+func NewTokenTransport(token string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tokenTransport{
+		token: token,
+		base:  base,
+	}
+}
+
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if t.token == "" {
+		return t.base.RoundTrip(r)
+	}
+	// a RoundTripper must not modify the request
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(r)
+}
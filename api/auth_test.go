@@ -0,0 +1,165 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestTokensRequire(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "reader", Scope: api.ScopeReadOnly, Hash: api.HashToken("ro-token")},
+		{Name: "writer", Scope: api.ScopeReadWrite, Hash: api.HashToken("rw-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(w http.ResponseWriter, _ *http.Request) {}
+
+	for _, tc := range []struct {
+		title  string
+		scope  api.Scope
+		header string
+		want   int
+	}{
+		{title: "no token", scope: api.ScopeReadOnly, want: http.StatusUnauthorized},
+		{title: "not bearer", scope: api.ScopeReadOnly, header: "Basic ro-token", want: http.StatusUnauthorized},
+		{title: "unknown token", scope: api.ScopeReadOnly, header: "Bearer unknown", want: http.StatusUnauthorized},
+		{title: "ro reads", scope: api.ScopeReadOnly, header: "Bearer ro-token", want: http.StatusOK},
+		{title: "ro cannot write", scope: api.ScopeReadWrite, header: "Bearer ro-token", want: http.StatusForbidden},
+		{title: "rw reads", scope: api.ScopeReadOnly, header: "Bearer rw-token", want: http.StatusOK},
+		{title: "rw writes", scope: api.ScopeReadWrite, header: "Bearer rw-token", want: http.StatusOK},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/get", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+			w := httptest.NewRecorder()
+			tokens.Require(tc.scope, ok)(w, r)
+			if w.Code != tc.want {
+				t.Errorf("want %d, got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestNewTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		title   string
+		content string
+		err     bool
+	}{
+		{title: "valid", content: `[{"name":"ci","scope":"rw","hash":"` + api.HashToken("x") + `"}]`},
+		{title: "unknown scope", content: `[{"name":"ci","scope":"admin","hash":"` + api.HashToken("x") + `"}]`, err: true},
+		{title: "plain token", content: `[{"name":"ci","scope":"ro","hash":"x"}]`, err: true},
+		{title: "broken", content: `[`, err: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			filename := filepath.Join(dir, tc.title)
+			if err := os.WriteFile(filename, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := api.NewTokenFile(filename)
+			if tc.err {
+				if !errors.Is(err, api.ErrInvalidToken) {
+					t.Errorf("want ErrInvalidToken, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestClientImplToken(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "reader", Scope: api.ScopeReadOnly, Hash: api.HashToken("ro-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t, &api.Record{Name: "docs", To: "https://example.com/docs"})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/get", tokens.Require(api.ScopeReadOnly, api.API(server.Get)))
+	mux.HandleFunc("/delete", tokens.Require(api.ScopeReadWrite, api.API(server.Delete)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	newClient := func(token string) api.Client {
+		return api.NewClientImpl(ts.URL, &http.Client{
+			Transport: api.NewTokenTransport(token, nil),
+		})
+	}
+	ctx := context.Background()
+
+	if _, err := newClient("").Get(ctx, "docs"); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("want ErrUnauthorized, got %v", err)
+	}
+	if _, err := newClient("ro-token").Get(ctx, "docs"); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	if err := newClient("ro-token").Delete(ctx, "docs"); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("want ErrForbidden, got %v", err)
+	}
+}
+
+func TestTokensRequireAudit(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "reader", Scope: api.ScopeReadOnly, Hash: api.HashToken("ro-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit := api.NewAuditMemory()
+	tokens.SetAuditLog(audit)
+	ok := func(w http.ResponseWriter, _ *http.Request) {}
+
+	for _, tc := range []struct {
+		route  string
+		scope  api.Scope
+		header string
+	}{
+		{route: "/put", scope: api.ScopeReadWrite},
+		{route: "/batch", scope: api.ScopeReadWrite, header: "Bearer ro-token"},
+		{route: "/get", scope: api.ScopeReadOnly},
+		{route: "/scan", scope: api.ScopeReadOnly, header: "Bearer ro-token"},
+	} {
+		r := httptest.NewRequest(http.MethodPost, tc.route, nil)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		tokens.Require(tc.scope, ok)(httptest.NewRecorder(), r)
+	}
+
+	got, err := audit.Query(context.Background(), &api.AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(got))
+	}
+	for i, want := range []struct {
+		op    api.AuditOp
+		actor string
+		err   error
+	}{
+		{op: api.AuditOpPut, err: api.ErrUnauthorized},
+		{op: api.AuditOpBatch, actor: "reader", err: api.ErrForbidden},
+	} {
+		e := got[i]
+		if e.Op != want.op || e.Actor != want.actor || !strings.HasPrefix(e.Error, want.err.Error()) || e.Remote == "" {
+			t.Errorf("entry %d: want %v, got %+v", i, want, e)
+		}
+	}
+}
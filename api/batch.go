@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+)
+
+// BatchOp is the change of a batch operation.
+type BatchOp string
+
+const (
+	BatchOpPut    BatchOp = "put"
+	BatchOpDelete BatchOp = "delete"
+)
+
+// BatchOperation is a put or a delete of a batch.
+type BatchOperation struct {
+	Op BatchOp `json:"op"`
+	// Record is the record of the put.
+	Record *Record `json:"record,omitempty"`
+	// Namespace and Name identify the record of the delete.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Precondition is checked including the changes of the earlier operations of the batch.
+	Precondition
+}
+
+// key returns the recordKey of the record of the operation.
+func (o *BatchOperation) key() string {
+	if o.Op == BatchOpPut {
+		return o.Record.key()
+	}
+	return recordKey(o.Namespace, o.Name)
+}
+
+// qualifiedName returns the name of the record of the operation with its namespace.
+func (o *BatchOperation) qualifiedName() string {
+	if o.Op == BatchOpPut {
+		return qualifiedName(o.Record.Namespace, o.Record.Name)
+	}
+	return qualifiedName(o.Namespace, o.Name)
+}
+
+// auditEntry returns the entry of the operation in the audit log.
+func (o *BatchOperation) auditEntry() *AuditEntry {
+	e := &AuditEntry{
+		// the ops of the batches are those of the audit log
+		Op:        AuditOp(o.Op),
+		Namespace: o.Namespace,
+		Name:      o.Name,
+		Record:    o.Record,
+	}
+	if o.Record != nil {
+		e.Namespace, e.Name = o.Record.Namespace, o.Record.Name
+	}
+	return e
+}
+
+func (o *BatchOperation) Validate() error {
+	switch o.Op {
+	case BatchOpPut:
+		if o.Record == nil {
+			return fmt.Errorf("%w, no record", ErrInvalidRecord)
+		}
+		if err := o.Record.Validate(); err != nil {
+			return err
+		}
+		return o.Precondition.Validate()
+	case BatchOpDelete:
+		if o.IfAbsent {
+			return fmt.Errorf("%w, if_absent on delete", ErrInvalidRecord)
+		}
+		return o.Precondition.Validate()
+	default:
+		return fmt.Errorf("%w, op %s is not one of %v", ErrInvalidRecord, o.Op, []BatchOp{BatchOpPut, BatchOpDelete})
+	}
+}
+
+// applyBatch returns the records with the operations applied in order, leaving records as is.
+func applyBatch(records []*Record, ops []*BatchOperation) ([]*Record, error) {
+	rs := append([]*Record(nil), records...)
+	for _, o := range ops {
+		index := -1
+		for i, r := range rs {
+			if r.key() == o.key() {
+				index = i
+				break
+			}
+		}
+		switch {
+		case o.Op == BatchOpPut && index < 0:
+			put := *o.Record
+			rs = append(rs, &put)
+		case o.Op == BatchOpPut:
+			put := *o.Record
+			rs[index] = &put
+		case index < 0:
+			return nil, fmt.Errorf("%w, %s", ErrRecordNotFound, o.qualifiedName())
+		default:
+			rs = append(rs[:index:index], rs[index+1:]...)
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,184 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestServerImplBatch(t *testing.T) {
+	ctx := context.Background()
+	scan := func(t *testing.T, server *api.ServerImpl) map[string]*api.Record {
+		t.Helper()
+		res, err := server.Scan(ctx, &api.ScanRequest{})
+		if err != nil && !errors.Is(err, api.ErrRecordNotFound) {
+			t.Fatal(err)
+		}
+		got := map[string]*api.Record{}
+		for _, r := range res.Records {
+			got[r.Name] = r
+		}
+		return got
+	}
+
+	t.Run("success", func(t *testing.T) {
+		server := newTestServer(t,
+			&api.Record{Name: "old", To: "https://example.com/old"},
+			&api.Record{Name: "keep", To: "https://example.com/keep"},
+		)
+		res, err := server.Batch(ctx, &api.BatchRequest{
+			Operations: []*api.BatchOperation{
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "new", To: "https://example.com/new"}},
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "keep", To: "https://example.com/kept"}, Precondition: api.Precondition{IfVersion: 1}},
+				{Op: api.BatchOpDelete, Name: "old"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Records) != 3 || res.Records[0].Version != 1 || res.Records[1].Version != 2 || res.Records[2] != nil {
+			t.Errorf("want versions 1, 2 and nil, got %v", res.Records)
+		}
+		got := scan(t, server)
+		if len(got) != 2 || got["new"] == nil || got["keep"].To != "https://example.com/kept" {
+			t.Errorf("want new and kept, got %v", got)
+		}
+
+		history, err := server.History(ctx, &api.HistoryRequest{Name: "old"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history.Revisions) != 2 || history.Revisions[1].Op != api.RevisionOpDelete {
+			t.Errorf("want the revision of the delete, got %v", history.Revisions)
+		}
+		audit, err := server.Audit(ctx, &api.AuditRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(audit.Entries) != 5 {
+			t.Errorf("want 5 entries, got %d", len(audit.Entries))
+		}
+	})
+
+	for _, tc := range []struct {
+		title string
+		ops   []*api.BatchOperation
+		err   error
+	}{
+		{
+			title: "no operations",
+			err:   api.ErrInvalidRecord,
+		},
+		{
+			title: "invalid op",
+			ops: []*api.BatchOperation{
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "new", To: "https://example.com/new"}},
+				{Op: "get", Name: "keep"},
+			},
+			err: api.ErrInvalidRecord,
+		},
+		{
+			title: "delete missing",
+			ops: []*api.BatchOperation{
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "new", To: "https://example.com/new"}},
+				{Op: api.BatchOpDelete, Name: "missing"},
+			},
+			err: api.ErrRecordNotFound,
+		},
+		{
+			title: "precondition",
+			ops: []*api.BatchOperation{
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "new", To: "https://example.com/new"}},
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "keep", To: "https://example.com/kept"}, Precondition: api.Precondition{IfAbsent: true}},
+			},
+			err: api.ErrConflict,
+		},
+		{
+			title: "precondition of an earlier operation",
+			ops: []*api.BatchOperation{
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "keep", To: "https://example.com/kept"}},
+				{Op: api.BatchOpDelete, Name: "keep", Precondition: api.Precondition{IfVersion: 1}},
+			},
+			err: api.ErrConflict,
+		},
+		{
+			title: "overlapping patterns",
+			ops: []*api.BatchOperation{
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "gh/{org}/{repo}", To: "https://github.com/{org}/{repo}"}},
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "gh/{owner}/{name}", To: "https://github.com/{owner}/{name}"}},
+			},
+			err: api.ErrInvalidRecord,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			server := newTestServer(t, &api.Record{Name: "keep", To: "https://example.com/keep"})
+			if _, err := server.Batch(ctx, &api.BatchRequest{Operations: tc.ops}); !errors.Is(err, tc.err) {
+				t.Fatalf("want %v, got %v", tc.err, err)
+			}
+			got := scan(t, server)
+			if len(got) != 1 || got["keep"].To != "https://example.com/keep" || got["keep"].Version != 1 {
+				t.Errorf("want the records unchanged, got %v", got)
+			}
+		})
+	}
+
+	t.Run("replace pattern", func(t *testing.T) {
+		server := newTestServer(t, &api.Record{Name: "gh/{org}/{repo}", To: "https://github.com/{org}/{repo}"})
+		if _, err := server.Batch(ctx, &api.BatchRequest{
+			Operations: []*api.BatchOperation{
+				{Op: api.BatchOpDelete, Name: "gh/{org}/{repo}"},
+				{Op: api.BatchOpPut, Record: &api.Record{Name: "gh/{owner}/{name}", To: "https://github.com/{owner}/{name}"}},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got := scan(t, server); len(got) != 1 || got["gh/{owner}/{name}"] == nil {
+			t.Errorf("want gh/{owner}/{name}, got %v", got)
+		}
+	})
+}
+
+func TestClientImplBatch(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "team", Scope: api.ScopeReadWrite, Hash: api.HashToken("team-token"), Namespaces: []string{"team"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/batch", tokens.Require(api.ScopeReadWrite, api.API(server.Batch)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	var (
+		ctx    = context.Background()
+		client = api.NewClientImpl(ts.URL, &http.Client{
+			Transport: api.NewTokenTransport("team-token", nil),
+		})
+		team = client.WithNamespace("team")
+	)
+	got, err := team.BatchPut(ctx, []*api.Record{
+		{Name: "x", To: "https://example.com/x"},
+		{Name: "y", To: "https://example.com/y"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Namespace != "team" || got[1].Version != 1 {
+		t.Errorf("want x and y of team, got %v", got)
+	}
+	if _, err := team.Batch(ctx, []*api.BatchOperation{
+		{Op: api.BatchOpDelete, Name: "x"},
+		{Op: api.BatchOpDelete, Name: "y", Precondition: api.Precondition{IfVersion: 2}},
+	}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("want ErrConflict, got %v", err)
+	}
+	if _, err := client.BatchPut(ctx, []*api.Record{{Name: "x", To: "https://example.com/x"}}); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("default namespace: want ErrForbidden, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	_ Database = &BoltDatabase{}
+
+	boltRecordsBucket = []byte("records")
+)
+
+// ErrImportNotEmpty means the store already has records, so the import would overwrite them.
+var ErrImportNotEmpty = errors.New("ImportNotEmpty")
+
+// BoltDatabase is a Database backed by an embedded key/value store.
+type BoltDatabase struct {
+	db    *bolt.DB
+	index lazyRecordIndex
+}
+
+// NewBoltDatabase opens or creates the store file.
+func NewBoltDatabase(filename string) (*BoltDatabase, error) {
+	db, err := bolt.Open(filename, 0666, &bolt.Options{
+		Timeout: 3 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, open %s %v", ErrConnectDatabase, filename, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltRecordsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w, create bucket %v", ErrConnectDatabase, err)
+	}
+	return &BoltDatabase{
+		db: db,
+	}, nil
+}
+
+func (db *BoltDatabase) Close() error {
+	return db.db.Close()
+}
+
+func (db *BoltDatabase) Scan(ctx context.Context) ([]*Record, error) {
+	var records []*Record
+	if err := db.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRecordsBucket).ForEach(func(_, v []byte) error {
+			r, err := unmarshalBoltRecord(v)
+			if err != nil {
+				return err
+			}
+			records = append(records, r)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return records, nil
+}
+
+func (db *BoltDatabase) Get(ctx context.Context, namespace, name string) (*Record, error) {
+	var record *Record
+	if err := db.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltRecordsBucket).Get([]byte(recordKey(namespace, name)))
+		if v == nil {
+			return fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
+		}
+		r, err := unmarshalBoltRecord(v)
+		if err != nil {
+			return err
+		}
+		record = r
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Index returns the index of the records, rebuilt by a scan after the writes.
+func (db *BoltDatabase) Index(ctx context.Context) (*RecordIndex, error) {
+	return db.index.get(ctx, db.Scan)
+}
+
+func (db *BoltDatabase) Put(ctx context.Context, record *Record) error {
+	defer db.index.invalidate()
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("%w, marshal", ErrWriteDatabase)
+	}
+	if err := db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRecordsBucket).Put([]byte(record.key()), b)
+	}); err != nil {
+		return fmt.Errorf("%w, put %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
+
+func (db *BoltDatabase) Delete(ctx context.Context, namespace, name string) error {
+	defer db.index.invalidate()
+	var (
+		notFound error
+		key      = []byte(recordKey(namespace, name))
+	)
+	if err := db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltRecordsBucket)
+		if bucket.Get(key) == nil {
+			notFound = fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
+			return nil
+		}
+		return bucket.Delete(key)
+	}); err != nil {
+		return fmt.Errorf("%w, delete %v", ErrWriteDatabase, err)
+	}
+	return notFound
+}
+
+func (db *BoltDatabase) Batch(ctx context.Context, ops []*BatchOperation) error {
+	defer db.index.invalidate()
+	var notFound error
+	if err := db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltRecordsBucket)
+		for _, o := range ops {
+			key := []byte(o.key())
+			if o.Op == BatchOpPut {
+				b, err := json.Marshal(o.Record)
+				if err != nil {
+					return err
+				}
+				if err := bucket.Put(key, b); err != nil {
+					return err
+				}
+				continue
+			}
+			if bucket.Get(key) == nil {
+				// roll back the transaction
+				notFound = fmt.Errorf("%w, %s", ErrRecordNotFound, o.qualifiedName())
+				return notFound
+			}
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		if notFound != nil {
+			return notFound
+		}
+		return fmt.Errorf("%w, batch %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
+
+// Import puts all records from the JSON database file into the empty store, ErrImportNotEmpty otherwise.
+func (db *BoltDatabase) Import(ctx context.Context, dbFile DatabaseFile) (int, error) {
+	defer db.index.invalidate()
+	records, err := dbFile.Read()
+	if err != nil {
+		return 0, err
+	}
+	if err := db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltRecordsBucket)
+		if k, _ := bucket.Cursor().First(); k != nil {
+			return ErrImportNotEmpty
+		}
+		for _, r := range records {
+			b, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(r.key()), b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, ErrImportNotEmpty) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w, import %v", ErrWriteDatabase, err)
+	}
+	return len(records), nil
+}
+
+func unmarshalBoltRecord(b []byte) (*Record, error) {
+	var r Record
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("%w, unmarshal", ErrReadDatabase)
+	}
+	return &r, nil
+}
@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+	"experimental-terraform-redirect-store/api/databasetest"
+)
+
+func TestBoltDatabase(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) api.Database {
+		db, err := api.NewBoltDatabase(filepath.Join(t.TempDir(), "api.bolt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+		})
+		return db
+	})
+}
+
+func TestBoltDatabaseImport(t *testing.T) {
+	ctx := context.Background()
+	db, err := api.NewBoltDatabase(filepath.Join(t.TempDir(), "api.bolt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, dbFile := newDatabaseFile(t)
+	if err := dbFile.Write([]*api.Record{
+		{Name: "a", To: "https://example.com/a"},
+		{Name: "b", To: "https://example.com/b"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.Import(ctx, dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("want 2 imported, got %d", n)
+	}
+	if err := db.Put(ctx, &api.Record{Name: "a", To: "https://example.com/changed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Import(ctx, dbFile); !errors.Is(err, api.ErrImportNotEmpty) {
+		t.Fatalf("want ErrImportNotEmpty, got %v", err)
+	}
+	got, err := db.Get(ctx, api.DefaultNamespace, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/changed"; got.To != want {
+		t.Errorf("want %s kept, got %s", want, got.To)
+	}
+}
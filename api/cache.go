@@ -0,0 +1,91 @@
+package api
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultCacheCheckInterval is how often DatabaseImpl looks for external changes to its files.
+const DefaultCacheCheckInterval = time.Second
+
+// FileStater is implemented by database files whose modification can be detected.
+type FileStater interface {
+	Stat() (os.FileInfo, error)
+}
+
+// recordCache is an in-memory index of the records read from the database files.
+type recordCache struct {
+	records []*Record
+	index   map[string]*Record
+	// recordIndex is the index of the records for the redirects
+	recordIndex *RecordIndex
+	// stats of the files the records were read from, nil if unknown
+	stats     []os.FileInfo
+	checkedAt time.Time
+}
+
+func newRecordCache(records []*Record, stats []os.FileInfo, now time.Time) *recordCache {
+	index := make(map[string]*Record, len(records))
+	for _, r := range records {
+		index[r.key()] = r
+	}
+	return &recordCache{
+		records:     records,
+		index:       index,
+		recordIndex: newRecordIndex(records),
+		stats:       stats,
+		checkedAt:   now,
+	}
+}
+
+// fresh reports whether the cache can be used without looking at the files.
+func (c *recordCache) fresh(now time.Time, interval time.Duration) bool {
+	return c != nil && c.stats != nil && now.Sub(c.checkedAt) < interval
+}
+
+// unchanged reports whether the files still have the stats the cache was built from.
+func (c *recordCache) unchanged(stats []os.FileInfo) bool {
+	return c != nil && sameFileStats(c.stats, stats)
+}
+
+// sameFileStats reports whether the files have not been modified between the stats.
+func sameFileStats(prev, stats []os.FileInfo) bool {
+	if prev == nil || stats == nil || len(prev) != len(stats) {
+		return false
+	}
+	for i, s := range stats {
+		p := prev[i]
+		switch {
+		case p == nil && s == nil:
+			continue
+		case p == nil || s == nil:
+			return false
+		case !os.SameFile(p, s), !p.ModTime().Equal(s.ModTime()), p.Size() != s.Size():
+			return false
+		}
+	}
+	return true
+}
+
+// statFiles returns the stats of the files, nil if any of them cannot be detected.
+func statFiles(files ...any) []os.FileInfo {
+	stats := make([]os.FileInfo, len(files))
+	for i, f := range files {
+		if f == nil {
+			continue
+		}
+		s, ok := f.(FileStater)
+		if !ok {
+			return nil
+		}
+		info, err := s.Stat()
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil
+		default:
+			stats[i] = info
+		}
+	}
+	return stats
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 var (
@@ -14,9 +15,36 @@ var (
 type Client interface {
 	Status(ctx context.Context) error
 	Scan(ctx context.Context) ([]*Record, error)
+	// ScanFiltered returns the records the filter selects, requesting them page by page.
+	ScanFiltered(ctx context.Context, filter ScanFilter) ([]*Record, error)
 	Get(ctx context.Context, name string) (*Record, error)
 	Put(ctx context.Context, record *Record) (*Record, error)
 	Delete(ctx context.Context, name string) error
+	// PutIf puts the record if the precondition holds, returning ErrConflict otherwise.
+	PutIf(ctx context.Context, record *Record, precondition Precondition) (*Record, error)
+	// DeleteIf deletes the record if the precondition holds, returning ErrConflict otherwise.
+	DeleteIf(ctx context.Context, name string, precondition Precondition) error
+	// Batch applies the operations atomically, returning the records of the operations in order.
+	Batch(ctx context.Context, ops []*BatchOperation) ([]*Record, error)
+	// BatchPut puts the records atomically.
+	BatchPut(ctx context.Context, records []*Record) ([]*Record, error)
+	GetFallback(ctx context.Context) (*Fallback, error)
+	PutFallback(ctx context.Context, fallback *Fallback) (*Fallback, error)
+	Stats(ctx context.Context, name string) (*Stats, error)
+	ScanDomains(ctx context.Context) ([]*Domain, error)
+	GetDomain(ctx context.Context, host string) (*Domain, error)
+	PutDomain(ctx context.Context, domain *Domain) (*Domain, error)
+	DeleteDomain(ctx context.Context, host string) error
+	// History returns the revisions of the record, oldest first.
+	History(ctx context.Context, name string) ([]*Revision, error)
+	// Rollback restores the record to the revision, returning nil if the revision deleted the record.
+	Rollback(ctx context.Context, name string, revision int) (*Record, error)
+	// Audit returns the calls changing the records between since and until, of the record if name is not empty.
+	Audit(ctx context.Context, since, until *time.Time, name string) ([]*AuditEntry, error)
+	// Namespace returns the namespace of the records of the client.
+	Namespace() string
+	// WithNamespace returns the client of the records in the namespace.
+	WithNamespace(namespace string) Client
 }
 
 func NewClientImpl(endpoint string, client *http.Client) *ClientImpl {
@@ -29,6 +57,28 @@ func NewClientImpl(endpoint string, client *http.Client) *ClientImpl {
 type ClientImpl struct {
 	endpoint string
 	client   *http.Client
+	// namespace of the records, DefaultNamespace if empty
+	namespace string
+	// number of the records per scan page, DefaultScanPageSize if zero
+	scanPageSize int
+}
+
+// SetScanPageSize changes the number of the records the client requests per scan page.
+func (c *ClientImpl) SetScanPageSize(n int) {
+	c.scanPageSize = n
+}
+
+func (c *ClientImpl) Namespace() string {
+	return c.namespace
+}
+
+func (c *ClientImpl) WithNamespace(namespace string) Client {
+	return &ClientImpl{
+		endpoint:     c.endpoint,
+		client:       c.client,
+		namespace:    namespace,
+		scanPageSize: c.scanPageSize,
+	}
 }
 
 func (c *ClientImpl) api(pattern string) string {
@@ -41,19 +91,47 @@ func (c *ClientImpl) Status(ctx context.Context) error {
 }
 
 func (c *ClientImpl) Scan(ctx context.Context) ([]*Record, error) {
-	r, err := Post[ScanRequest, ScanResponse](c.client, c.api("/scan"))(ctx, ScanRequest{})
-	if err != nil {
-		return nil, err
+	return c.ScanFiltered(ctx, ScanFilter{})
+}
+
+func (c *ClientImpl) ScanFiltered(ctx context.Context, filter ScanFilter) ([]*Record, error) {
+	limit := c.scanPageSize
+	if limit == 0 {
+		limit = DefaultScanPageSize
 	}
-	if r.Error != "" {
-		return nil, errors.New(r.Error)
+	var (
+		records []*Record
+		token   string
+	)
+	for {
+		r, err := Post[ScanRequest, ScanResponse](c.client, c.api("/scan"))(ctx, ScanRequest{
+			Namespace:         c.namespace,
+			ScanFilter:        filter,
+			Limit:             limit,
+			ContinuationToken: token,
+		})
+		switch {
+		case errors.Is(err, ErrNotFound) && token != "":
+			// the records after the previous page were deleted
+			return records, nil
+		case err != nil:
+			return nil, err
+		}
+		if r.Error != "" {
+			return nil, errors.New(r.Error)
+		}
+		records = append(records, r.Records...)
+		if r.ContinuationToken == "" {
+			return records, nil
+		}
+		token = r.ContinuationToken
 	}
-	return r.Records, nil
 }
 
 func (c *ClientImpl) Get(ctx context.Context, name string) (*Record, error) {
 	r, err := Post[GetRequest, GetResponse](c.client, c.api("/get"))(ctx, GetRequest{
-		Name: name,
+		Namespace: c.namespace,
+		Name:      name,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%w, %s", err, name)
@@ -65,8 +143,18 @@ func (c *ClientImpl) Get(ctx context.Context, name string) (*Record, error) {
 }
 
 func (c *ClientImpl) Put(ctx context.Context, record *Record) (*Record, error) {
+	return c.PutIf(ctx, record, Precondition{})
+}
+
+func (c *ClientImpl) PutIf(ctx context.Context, record *Record, precondition Precondition) (*Record, error) {
+	if record.Namespace == DefaultNamespace {
+		put := *record
+		put.Namespace = c.namespace
+		record = &put
+	}
 	r, err := Post[PutRequest, PutResponse](c.client, c.api("/put"))(ctx, PutRequest{
-		Record: record,
+		Record:       record,
+		Precondition: precondition,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%w, %v", err, record)
@@ -78,8 +166,14 @@ func (c *ClientImpl) Put(ctx context.Context, record *Record) (*Record, error) {
 }
 
 func (c *ClientImpl) Delete(ctx context.Context, name string) error {
+	return c.DeleteIf(ctx, name, Precondition{})
+}
+
+func (c *ClientImpl) DeleteIf(ctx context.Context, name string, precondition Precondition) error {
 	r, err := Post[DeleteRequest, DeleteResponse](c.client, c.api("/delete"))(ctx, DeleteRequest{
-		Name: name,
+		Namespace:    c.namespace,
+		Name:         name,
+		Precondition: precondition,
 	})
 	if err != nil {
 		return fmt.Errorf("%w, %s", err, name)
@@ -89,3 +183,173 @@ func (c *ClientImpl) Delete(ctx context.Context, name string) error {
 	}
 	return nil
 }
+
+func (c *ClientImpl) GetFallback(ctx context.Context) (*Fallback, error) {
+	r, err := Post[GetFallbackRequest, GetFallbackResponse](c.client, c.api("/fallback/get"))(ctx, GetFallbackRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+	return r.Fallback, nil
+}
+
+func (c *ClientImpl) PutFallback(ctx context.Context, fallback *Fallback) (*Fallback, error) {
+	r, err := Post[PutFallbackRequest, PutFallbackResponse](c.client, c.api("/fallback/put"))(ctx, PutFallbackRequest{
+		Fallback: fallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, %v", err, fallback)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, %v", r.Error, fallback)
+	}
+	return r.Fallback, nil
+}
+
+func (c *ClientImpl) Stats(ctx context.Context, name string) (*Stats, error) {
+	r, err := Post[StatsRequest, StatsResponse](c.client, c.api("/stats"))(ctx, StatsRequest{
+		Namespace: c.namespace,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, %s", err, name)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, %s", r.Error, name)
+	}
+	return r.Stats, nil
+}
+
+func (c *ClientImpl) ScanDomains(ctx context.Context) ([]*Domain, error) {
+	r, err := Post[ScanDomainsRequest, ScanDomainsResponse](c.client, c.api("/domain/scan"))(ctx, ScanDomainsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+	return r.Domains, nil
+}
+
+func (c *ClientImpl) GetDomain(ctx context.Context, host string) (*Domain, error) {
+	r, err := Post[GetDomainRequest, GetDomainResponse](c.client, c.api("/domain/get"))(ctx, GetDomainRequest{
+		Host: host,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, %s", err, host)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, %s", r.Error, host)
+	}
+	return r.Domain, nil
+}
+
+func (c *ClientImpl) PutDomain(ctx context.Context, domain *Domain) (*Domain, error) {
+	r, err := Post[PutDomainRequest, PutDomainResponse](c.client, c.api("/domain/put"))(ctx, PutDomainRequest{
+		Domain: domain,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, %v", err, domain)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, %v", r.Error, domain)
+	}
+	return r.Domain, nil
+}
+
+func (c *ClientImpl) DeleteDomain(ctx context.Context, host string) error {
+	r, err := Post[DeleteDomainRequest, DeleteDomainResponse](c.client, c.api("/domain/delete"))(ctx, DeleteDomainRequest{
+		Host: host,
+	})
+	if err != nil {
+		return fmt.Errorf("%w, %s", err, host)
+	}
+	if r.Error != "" {
+		return fmt.Errorf("%s, %s", r.Error, host)
+	}
+	return nil
+}
+
+func (c *ClientImpl) History(ctx context.Context, name string) ([]*Revision, error) {
+	r, err := Post[HistoryRequest, HistoryResponse](c.client, c.api("/history"))(ctx, HistoryRequest{
+		Namespace: c.namespace,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, %s", err, name)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, %s", r.Error, name)
+	}
+	return r.Revisions, nil
+}
+
+func (c *ClientImpl) Rollback(ctx context.Context, name string, revision int) (*Record, error) {
+	r, err := Post[RollbackRequest, RollbackResponse](c.client, c.api("/rollback"))(ctx, RollbackRequest{
+		Namespace: c.namespace,
+		Name:      name,
+		Revision:  revision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w, %s revision %d", err, name, revision)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, %s revision %d", r.Error, name, revision)
+	}
+	return r.Record, nil
+}
+
+func (c *ClientImpl) Audit(ctx context.Context, since, until *time.Time, name string) ([]*AuditEntry, error) {
+	r, err := Post[AuditRequest, AuditResponse](c.client, c.api("/audit"))(ctx, AuditRequest{
+		Since:     since,
+		Until:     until,
+		Namespace: c.namespace,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+	return r.Entries, nil
+}
+
+func (c *ClientImpl) Batch(ctx context.Context, ops []*BatchOperation) ([]*Record, error) {
+	req := BatchRequest{
+		Operations: make([]*BatchOperation, len(ops)),
+	}
+	for i, o := range ops {
+		op := *o
+		if op.Namespace == DefaultNamespace {
+			op.Namespace = c.namespace
+		}
+		if op.Record != nil && op.Record.Namespace == DefaultNamespace {
+			put := *op.Record
+			put.Namespace = c.namespace
+			op.Record = &put
+		}
+		req.Operations[i] = &op
+	}
+	r, err := Post[BatchRequest, BatchResponse](c.client, c.api("/batch"))(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w, batch of %d operations", err, len(ops))
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s, batch of %d operations", r.Error, len(ops))
+	}
+	return r.Records, nil
+}
+
+func (c *ClientImpl) BatchPut(ctx context.Context, records []*Record) ([]*Record, error) {
+	ops := make([]*BatchOperation, len(records))
+	for i, r := range records {
+		ops[i] = &BatchOperation{
+			Op:     BatchOpPut,
+			Record: r,
+		}
+	}
+	return c.Batch(ctx, ops)
+}
@@ -9,17 +9,47 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 const usage = `api-client
 
 Usage:
   api-client status
-  api-client scan
+  api-client scan [-name_prefix PREFIX] [-to_contains SUBSTR] [-to_regexp REGEXP]
   api-client get NAME
-  api-client put NAME TO
-  api-clinet delete NAME
+  api-client put [-status_code CODE] [-match exact|prefix] [-query drop|pass|merge_request|merge_target]
+                 [-not_before RFC3339] [-expires_at RFC3339] [-if_version VERSION | -if_absent] NAME TO
+  api-clinet delete [-if_version VERSION] NAME
+  api-client stats NAME
+  api-client apply FILE
+  api-client history NAME
+  api-client rollback NAME REV
+  api-client audit [-since RFC3339] [-until RFC3339] [NAME]
+  api-client fallback get
+  api-client fallback put [-mode none|redirect|suggest|record] [-to TO] [-record NAME] [-suggestions N]
+  api-client domain scan
+  api-client domain get HOST
+  api-client domain put HOST NAMESPACE
+  api-client domain delete HOST
+  api-client token [-scope ro|rw] [-namespaces NS,...] NAME
+
+The apply command puts and deletes the records of the operations in the JSON or YAML FILE atomically:
+
+  - op: put
+    record: {name: docs, to: "https://example.com/docs"}
+  - op: delete
+    name: old
+    if_version: 3
+
+The token command generates a token and its entry of the token file of api-server.
+The token is read from REDIRECT_STORE_TOKEN if -token is not given.
+The records are in the namespace of -namespace, REDIRECT_STORE_NAMESPACE if not given,
+the default namespace if empty.
 
 Flags:`
 
@@ -31,19 +61,31 @@ func Usage() {
 func main() {
 	var (
 		endpoint = flag.String("endpoint", "http://127.0.0.1:8030", "")
+		token    = flag.String("token", os.Getenv("REDIRECT_STORE_TOKEN"), "bearer token of the management API")
+		caFile   = flag.String("ca", "", "PEM CA bundle verifying the server, the system roots if empty")
+		certFile = flag.String("cert", "", "PEM client certificate file for mutual TLS")
+		keyFile  = flag.String("key", "", "PEM key file of -cert")
+
+		namespace = flag.String("namespace", os.Getenv("REDIRECT_STORE_NAMESPACE"), "namespace of the records")
 	)
 	flag.Usage = Usage
 	flag.Parse()
 
+	tlsConfig, err := api.NewClientTLSConfig(*caFile, *certFile, *keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v", err)
+		os.Exit(1)
+	}
 	client := api.NewClientImpl(
 		*endpoint,
 		&http.Client{
-			Timeout: 3 * time.Second,
+			Timeout:   3 * time.Second,
+			Transport: api.NewTokenTransport(*token, api.NewTransport(tlsConfig)),
 		},
 	)
 
 	args := flag.Args()
-	r, err := send(context.Background(), client, args)
+	r, err := send(context.Background(), client.WithNamespace(*namespace), args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v", err)
 		os.Exit(1)
@@ -69,27 +111,267 @@ func send(ctx context.Context, c api.Client, args []string) (any, error) {
 		err := c.Status(ctx)
 		return nil, err
 	case "scan":
-		return c.Scan(ctx)
+		fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+		var filter api.ScanFilter
+		fs.StringVar(&filter.NamePrefix, "name_prefix", "", "select the records whose name starts with the prefix")
+		fs.StringVar(&filter.ToContains, "to_contains", "", "select the records whose to contains the string")
+		fs.StringVar(&filter.ToRegexp, "to_regexp", "", "select the records whose to matches the regular expression")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+		}
+		return c.ScanFiltered(ctx, filter)
 	case "get":
 		if len(args) < 2 {
 			return nil, ErrInvalidArgument
 		}
 		return c.Get(ctx, args[1])
+	case "put":
+		record, precondition, err := parsePut(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		return c.PutIf(ctx, record, precondition)
+	case "delete":
+		fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+		ifVersion := fs.Int64("if_version", 0, "delete only if the record is at the version")
+		if err := fs.Parse(args[1:]); err != nil {
+			return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+		}
+		if fs.NArg() < 1 {
+			return nil, ErrInvalidArgument
+		}
+		err := c.DeleteIf(ctx, fs.Arg(0), api.Precondition{IfVersion: *ifVersion})
+		return nil, err
+	case "stats":
+		if len(args) < 2 {
+			return nil, ErrInvalidArgument
+		}
+		return c.Stats(ctx, args[1])
+	case "apply":
+		if len(args) < 2 {
+			return nil, ErrInvalidArgument
+		}
+		ops, err := readBatch(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return c.Batch(ctx, ops)
+	case "history":
+		if len(args) < 2 {
+			return nil, ErrInvalidArgument
+		}
+		return c.History(ctx, args[1])
+	case "rollback":
+		if len(args) < 3 {
+			return nil, ErrInvalidArgument
+		}
+		revision, err := strconv.Atoi(args[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w, revision %s", ErrInvalidArgument, args[2])
+		}
+		return c.Rollback(ctx, args[1], revision)
+	case "audit":
+		return sendAudit(ctx, c, args[1:])
+	case "fallback":
+		return sendFallback(ctx, c, args[1:])
+	case "domain":
+		return sendDomain(ctx, c, args[1:])
+	case "token":
+		return generateToken(args[1:])
+	default:
+		return nil, fmt.Errorf("%w, unknown command %s", ErrInvalidArgument, args[0])
+	}
+}
+
+func parsePut(args []string) (*api.Record, api.Precondition, error) {
+	fs := flag.NewFlagSet("put", flag.ContinueOnError)
+	var (
+		statusCode = fs.Int("status_code", 0, "HTTP status code of the redirect")
+		match      = fs.String("match", "", "exact or prefix")
+		query      = fs.String("query", "", "drop, pass, merge_request or merge_target")
+		notBefore  = fs.String("not_before", "", "RFC3339 timestamp when the record starts redirecting")
+		expiresAt  = fs.String("expires_at", "", "RFC3339 timestamp when the record stops redirecting")
+		ifVersion  = fs.Int64("if_version", 0, "put only if the record is at the version")
+		ifAbsent   = fs.Bool("if_absent", false, "put only if the record does not exist")
+	)
+	if err := fs.Parse(args); err != nil {
+		return nil, api.Precondition{}, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+	}
+	if fs.NArg() < 2 {
+		return nil, api.Precondition{}, ErrInvalidArgument
+	}
+	nb, err := parseTime(*notBefore)
+	if err != nil {
+		return nil, api.Precondition{}, fmt.Errorf("%w, not_before %v", ErrInvalidArgument, err)
+	}
+	ea, err := parseTime(*expiresAt)
+	if err != nil {
+		return nil, api.Precondition{}, fmt.Errorf("%w, expires_at %v", ErrInvalidArgument, err)
+	}
+	return &api.Record{
+		Name:       fs.Arg(0),
+		To:         fs.Arg(1),
+		StatusCode: *statusCode,
+		Match:      api.MatchMode(*match),
+		Query:      api.QueryPolicy(*query),
+		NotBefore:  nb,
+		ExpiresAt:  ea,
+	}, api.Precondition{IfVersion: *ifVersion, IfAbsent: *ifAbsent}, nil
+}
+
+// readBatch reads the operations from the YAML file, JSON being a subset of YAML.
+func readBatch(filename string) ([]*api.BatchOperation, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+	}
+	// decode to generic values first so that the JSON tags of the operations apply
+	var v any
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("%w, parse %s %v", ErrInvalidArgument, filename, err)
+	}
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w, parse %s %v", ErrInvalidArgument, filename, err)
+	}
+	var ops []*api.BatchOperation
+	if err := json.Unmarshal(j, &ops); err != nil {
+		return nil, fmt.Errorf("%w, parse %s %v", ErrInvalidArgument, filename, err)
+	}
+	return ops, nil
+}
+
+func sendAudit(ctx context.Context, c api.Client, args []string) (any, error) {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	var (
+		since = fs.String("since", "", "RFC3339 timestamp of the first calls")
+		until = fs.String("until", "", "RFC3339 timestamp after the last calls")
+	)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+	}
+	s, err := parseTime(*since)
+	if err != nil {
+		return nil, fmt.Errorf("%w, since %v", ErrInvalidArgument, err)
+	}
+	u, err := parseTime(*until)
+	if err != nil {
+		return nil, fmt.Errorf("%w, until %v", ErrInvalidArgument, err)
+	}
+	return c.Audit(ctx, s, u, fs.Arg(0))
+}
+
+func sendFallback(ctx context.Context, c api.Client, args []string) (any, error) {
+	if len(args) == 0 {
+		return nil, ErrInvalidArgument
+	}
+	switch args[0] {
+	case "get":
+		return c.GetFallback(ctx)
+	case "put":
+		fallback, err := parseFallback(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		return c.PutFallback(ctx, fallback)
+	default:
+		return nil, fmt.Errorf("%w, unknown fallback command %s", ErrInvalidArgument, args[0])
+	}
+}
+
+func sendDomain(ctx context.Context, c api.Client, args []string) (any, error) {
+	if len(args) == 0 {
+		return nil, ErrInvalidArgument
+	}
+	switch args[0] {
+	case "scan":
+		return c.ScanDomains(ctx)
+	case "get":
+		if len(args) < 2 {
+			return nil, ErrInvalidArgument
+		}
+		return c.GetDomain(ctx, args[1])
 	case "put":
 		if len(args) < 3 {
 			return nil, ErrInvalidArgument
 		}
-		return c.Put(ctx, &api.Record{
-			Name: args[1],
-			To:   args[2],
+		return c.PutDomain(ctx, &api.Domain{
+			Host:      args[1],
+			Namespace: args[2],
 		})
 	case "delete":
 		if len(args) < 2 {
 			return nil, ErrInvalidArgument
 		}
-		err := c.Delete(ctx, args[1])
+		err := c.DeleteDomain(ctx, args[1])
 		return nil, err
 	default:
-		return nil, fmt.Errorf("%w, unknown command %s", ErrInvalidArgument, args[0])
+		return nil, fmt.Errorf("%w, unknown domain command %s", ErrInvalidArgument, args[0])
+	}
+}
+
+func parseFallback(args []string) (*api.Fallback, error) {
+	fs := flag.NewFlagSet("fallback put", flag.ContinueOnError)
+	var (
+		mode        = fs.String("mode", string(api.FallbackNone), "none, redirect, suggest or record")
+		to          = fs.String("to", "", "redirect-to of the redirect mode")
+		record      = fs.String("record", "", "name of the fallback records of the record mode")
+		suggestions = fs.Int("suggestions", 0, "max number of names the suggest mode lists")
+	)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+	}
+	return &api.Fallback{
+		Mode:        api.FallbackMode(*mode),
+		To:          *to,
+		Record:      *record,
+		Suggestions: *suggestions,
+	}, nil
+}
+
+func generateToken(args []string) (any, error) {
+	fs := flag.NewFlagSet("token", flag.ContinueOnError)
+	var (
+		scope      = fs.String("scope", string(api.ScopeReadOnly), "ro or rw")
+		namespaces = fs.String("namespaces", "", "comma separated namespaces the token is limited to, all the namespaces if empty")
+	)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+	}
+	if fs.NArg() < 1 {
+		return nil, ErrInvalidArgument
+	}
+	token, err := api.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	entry := &api.TokenEntry{
+		Name:  fs.Arg(0),
+		Scope: api.Scope(*scope),
+		Hash:  api.HashToken(token),
+	}
+	if *namespaces != "" {
+		entry.Namespaces = strings.Split(*namespaces, ",")
+	}
+	if _, err := api.NewTokens([]*api.TokenEntry{entry}); err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInvalidArgument, err)
+	}
+	return struct {
+		Token string          `json:"token"`
+		Entry *api.TokenEntry `json:"entry"`
+	}{
+		Token: token,
+		Entry: entry,
+	}, nil
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
 	}
+	return &t, nil
 }
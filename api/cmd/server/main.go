@@ -1,28 +1,242 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"experimental-terraform-redirect-store/api"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"time"
 )
 
 func main() {
 	var (
-		addr = flag.String("addr", "127.0.0.1:8030", "")
-		db   = flag.String("db", "api.db", "DB file")
+		addr       = flag.String("addr", "127.0.0.1:8030", "")
+		db         = flag.String("db", "", "DB file, default api.db for the file backend, api.bolt for the bolt backend and api.sqlite for the sqlite backend")
+		backend    = flag.String("backend", "file", "DB backend, file, bolt or sqlite")
+		dsn        = flag.String("dsn", "", "data source name of the sqlite backend, default DB file")
+		importFile = flag.String("import", "", "JSON DB file to import into the bolt backend before serving, skipped if it has records")
+		journal    = flag.String("journal", "", "journal file of the file backend, default DB file + .journal")
+		compact    = flag.Int("compact", 100, "compact the journal into the DB file after this many writes")
+		compactInt = flag.Duration("compact-interval", api.DefaultCompactInterval, "compact the journal into the DB file at this interval as well, disabled if zero")
+		cacheCheck = flag.Duration("cache-check", api.DefaultCacheCheckInterval, "how often the file backend checks the DB file for external changes")
+		gone       = flag.Bool("gone", false, "respond 410 Gone instead of 404 Not Found to the redirects of expired records")
+		sweep      = flag.Duration("sweep", 0, "delete expired records at this interval, disabled if zero")
+		fallback   = flag.String("fallback", "", "file to persist the fallback for unknown names, not persisted if empty")
+		fbMode     = flag.String("fallback-mode", string(api.FallbackNone), "initial fallback mode, none, redirect, suggest or record, ignored if the -fallback file exists")
+		fbTo       = flag.String("fallback-to", "", "initial redirect-to of the redirect fallback, ignored if the -fallback file exists")
+		fbRecord   = flag.String("fallback-record", "", "initial record name of the record fallback, ignored if the -fallback file exists")
+		domains    = flag.String("domains", "", "file to persist the domains selecting the namespaces by the hosts, not persisted if empty")
+		history    = flag.String("history", "", "file to persist the revisions of the records, not persisted if empty")
+		audit      = flag.String("audit", "", "file to append the audit log of the calls changing the records, not persisted if empty")
+		auditSize  = flag.Int64("audit-max-size", api.DefaultAuditMaxSize, "size in bytes the audit log file is rotated at, not rotated if zero")
+		auditKeep  = flag.Int("audit-backups", api.DefaultAuditBackups, "number of the rotated audit log files kept")
+		hits       = flag.String("hits", "", "file to persist the hits of the records, not persisted if empty")
+		hitsBuffer = flag.Int("hits-buffer", api.DefaultHitBufferSize, "number of hits buffered before writing, hits beyond are dropped, default if less than 1")
+		hitsFlush  = flag.Duration("hits-flush", api.DefaultHitFlushInterval, "how often the buffered hits are written")
+		tokenFile  = flag.String("tokens", "", "JSON file of the hashed tokens the management API requires, no authentication if empty")
+		tlsCert    = flag.String("tls-cert", "", "PEM certificate file to serve HTTPS, reloaded when changed")
+		tlsKey     = flag.String("tls-key", "", "PEM key file of -tls-cert")
+		clientCA   = flag.String("tls-client-ca", "", "PEM CA bundle verifying the client certificates the management API requires")
 	)
 	flag.Parse()
 
-	if err := touchFile(*db); err != nil {
+	if *db == "" {
+		*db = defaultDBFile(*backend)
+	}
+	database, err := newDatabase(*backend, *db, *dsn, *importFile, *journal, *compact, *compactInt, *cacheCheck)
+	if err != nil {
+		panic(err)
+	}
+	metrics := api.NewMetrics()
+	server := api.NewServerImpl(api.InstrumentDatabase(database, metrics))
+	server.SetGoneOnExpired(*gone)
+	fallbackStore, err := newFallbackStore(*fallback, &api.Fallback{
+		Mode:   api.FallbackMode(*fbMode),
+		To:     *fbTo,
+		Record: *fbRecord,
+	}, isFlagSet("fallback-mode", "fallback-to", "fallback-record"))
+	if err != nil {
+		panic(err)
+	}
+	server.SetFallbackStore(fallbackStore)
+	domainStore, err := newDomainStore(*domains)
+	if err != nil {
+		panic(err)
+	}
+	server.SetDomainStore(domainStore)
+	revisionStore, err := newRevisionStore(*history)
+	if err != nil {
+		panic(err)
+	}
+	server.SetRevisionStore(revisionStore)
+	auditLog := newAuditLog(*audit, *auditSize, *auditKeep)
+	server.SetAuditLog(auditLog)
+	hitStore, err := newHitStore(*hits)
+	if err != nil {
+		panic(err)
+	}
+	recorder := api.NewHitRecorder(hitStore, *hitsBuffer, *hitsFlush)
+	metrics.RegisterHitRecorder(recorder)
+	go recorder.Run(context.Background())
+	server.SetHitRecorder(recorder)
+	if *sweep > 0 {
+		go api.NewSweeper(server, *sweep).Run(context.Background())
+	}
+	var tokens *api.Tokens
+	if *tokenFile != "" {
+		if tokens, err = api.NewTokenFile(*tokenFile); err != nil {
+			panic(err)
+		}
+		tokens.SetAuditLog(auditLog)
+	}
+	tlsConfig, err := newTLSConfig(*tlsCert, *tlsKey, *clientCA)
+	if err != nil {
 		panic(err)
 	}
-	dbFile := api.NewDatabaseFile(*db)
-	database := api.NewDatabaseImpl(dbFile)
-	server := api.NewServerImpl(database)
-	slog.Info("listen", slog.String("addr", *addr), slog.String("db", *db))
-	panic(api.ListenAndServe(*addr, server, server))
+	slog.Info("listen", slog.String("addr", *addr), slog.String("db", *db), slog.String("backend", *backend), slog.Bool("tls", tlsConfig != nil))
+	panic(api.ListenAndServe(*addr, server, server, metrics, tokens, tlsConfig))
+}
+
+func newDatabase(backend, db, dsn, importFile, journal string, compact int, compactInterval, cacheCheck time.Duration) (api.Database, error) {
+	switch backend {
+	case "file":
+		if importFile != "" {
+			return nil, errors.New("import is only available for the bolt backend")
+		}
+		if err := touchFile(db); err != nil {
+			return nil, err
+		}
+		if journal == "" {
+			journal = db + ".journal"
+		}
+		journalFile, err := api.NewJournalFile(journal)
+		if err != nil {
+			return nil, err
+		}
+		dbFile := api.NewDatabaseFile(db)
+		database := api.NewJournaledDatabaseImpl(dbFile, journalFile, compact)
+		database.SetCacheCheckInterval(cacheCheck)
+		// replay the journal left by the previous run
+		if err := database.Compact(context.Background()); err != nil {
+			return nil, err
+		}
+		if compactInterval > 0 {
+			go database.RunCompaction(context.Background(), compactInterval)
+		}
+		return database, nil
+	case "bolt":
+		database, err := api.NewBoltDatabase(db)
+		if err != nil {
+			return nil, err
+		}
+		if importFile != "" {
+			n, err := database.Import(context.Background(), api.NewDatabaseFile(importFile))
+			switch {
+			case errors.Is(err, api.ErrImportNotEmpty):
+				slog.Warn("skip import, the DB has records", slog.String("file", importFile), slog.String("db", db))
+			case err != nil:
+				return nil, err
+			default:
+				slog.Info("import", slog.String("file", importFile), slog.Int("records", n))
+			}
+		}
+		return database, nil
+	case "sqlite":
+		if importFile != "" {
+			return nil, errors.New("import is only available for the bolt backend")
+		}
+		if dsn == "" {
+			dsn = db
+		}
+		return api.NewSQLDatabase(context.Background(), dsn)
+	default:
+		return nil, fmt.Errorf("unknown backend %s", backend)
+	}
+}
+
+// defaultDBFile returns the DB file of the backend if -db is not given.
+func defaultDBFile(backend string) string {
+	switch backend {
+	case "bolt":
+		return "api.bolt"
+	case "sqlite":
+		return "api.sqlite"
+	default:
+		return "api.db"
+	}
+}
+
+func newDomainStore(file string) (api.DomainStore, error) {
+	if file == "" {
+		return api.NewDomainMemory(), nil
+	}
+	return api.NewDomainFile(file)
+}
+
+func newRevisionStore(file string) (api.RevisionStore, error) {
+	if file == "" {
+		return api.NewRevisionMemory(), nil
+	}
+	return api.NewRevisionFile(file)
+}
+
+func newAuditLog(file string, maxSize int64, backups int) api.AuditLog {
+	if file == "" {
+		return api.NewAuditMemory()
+	}
+	return api.NewAuditFile(file, maxSize, backups)
+}
+
+// newFallbackStore returns the store of the fallback, warning if the file overrides the flags.
+func newFallbackStore(file string, initial *api.Fallback, initialSet bool) (api.FallbackStore, error) {
+	if err := initial.Validate(); err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return api.NewFallbackMemory(initial), nil
+	}
+	store, err := api.NewFallbackFile(file, initial)
+	if err != nil {
+		return nil, err
+	}
+	current, err := store.Get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if initialSet && *current != *initial {
+		slog.Warn("ignore the fallback flags, the fallback file exists",
+			slog.String("file", file),
+			slog.String("mode", string(current.Mode)),
+			slog.String("to", current.To),
+			slog.String("record", current.Record))
+	}
+	return store, nil
+}
+
+func newHitStore(file string) (api.HitStore, error) {
+	if file == "" {
+		return api.NewHitMemory(), nil
+	}
+	return api.NewHitFile(file)
+}
+
+func newTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	switch {
+	case certFile == "" && keyFile == "" && clientCAFile == "":
+		return nil, nil
+	case certFile == "" || keyFile == "":
+		return nil, errors.New("tls-cert and tls-key are required to serve HTTPS")
+	}
+	reloader, err := api.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewServerTLSConfig(reloader, clientCAFile)
 }
 
 func touchFile(name string) error {
@@ -40,3 +254,14 @@ func touchFile(name string) error {
 		return nil
 	}
 }
+
+// isFlagSet reports whether any of the flags is set on the command line.
+func isFlagSet(names ...string) bool {
+	var set bool
+	flag.Visit(func(f *flag.Flag) {
+		if slices.Contains(names, f.Name) {
+			set = true
+		}
+	})
+	return set
+}
@@ -5,15 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 )
 
-type Record struct {
-	Name string `json:"name"`
-	To   string `json:"to"`
-}
-
 var (
 	ErrRecordNotFound = errors.New("RecordNotFound")
 
@@ -23,85 +21,263 @@ var (
 // NOTE: make a simple implementation for verification purposes
 
 type Database interface {
+	// Scan returns the records of all the namespaces.
 	Scan(ctx context.Context) ([]*Record, error)
-	Get(ctx context.Context, name string) (*Record, error)
+	Get(ctx context.Context, namespace, name string) (*Record, error)
 	Put(ctx context.Context, record *Record) error
-	Delete(ctx context.Context, name string) error
+	Delete(ctx context.Context, namespace, name string) error
+	// Batch applies the operations in order atomically without checking the preconditions.
+	Batch(ctx context.Context, ops []*BatchOperation) error
+	// Index returns the index of the records for the redirects, rebuilt when the records change.
+	Index(ctx context.Context) (*RecordIndex, error)
 }
 
 func NewDatabaseImpl(dbFile DatabaseFile) *DatabaseImpl {
 	return &DatabaseImpl{
-		dbFile: dbFile,
-		mux:    sync.RWMutex{},
+		dbFile:             dbFile,
+		cacheCheckInterval: DefaultCacheCheckInterval,
+		mux:                sync.RWMutex{},
 	}
 }
 
+// NewJournaledDatabaseImpl returns a DatabaseImpl journaling the writes, compacted every compactThreshold entries.
+func NewJournaledDatabaseImpl(dbFile DatabaseFile, journal Journal, compactThreshold int) *DatabaseImpl {
+	return &DatabaseImpl{
+		dbFile:             dbFile,
+		journal:            journal,
+		compactThreshold:   compactThreshold,
+		cacheCheckInterval: DefaultCacheCheckInterval,
+		mux:                sync.RWMutex{},
+	}
+}
+
+// DatabaseImpl caches the records, reloading them when the files are modified by others.
 type DatabaseImpl struct {
-	dbFile DatabaseFile
-	mux    sync.RWMutex
+	dbFile             DatabaseFile
+	journal            Journal
+	compactThreshold   int
+	cache              *recordCache
+	cacheCheckInterval time.Duration
+	mux                sync.RWMutex
 }
 
-func (db *DatabaseImpl) Scan(ctx context.Context) ([]*Record, error) {
-	db.mux.RLock()
-	defer db.mux.RUnlock()
+// SetCacheCheckInterval changes how often the files are checked for external changes.
+func (db *DatabaseImpl) SetCacheCheckInterval(d time.Duration) {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	db.cacheCheckInterval = d
+}
 
-	records, err := db.dbFile.Read()
+// cached returns the cache if it is fresh. Requires the read lock.
+func (db *DatabaseImpl) cached() (*recordCache, bool) {
+	if db.cache.fresh(time.Now(), db.cacheCheckInterval) {
+		return db.cache, true
+	}
+	return nil, false
+}
+
+// load returns the cache, reloading it if the files have changed. Requires the write lock.
+func (db *DatabaseImpl) load() (*recordCache, error) {
+	now := time.Now()
+	if db.cache.fresh(now, db.cacheCheckInterval) {
+		return db.cache, nil
+	}
+	stats := db.stat()
+	if db.cache.unchanged(stats) {
+		db.cache.checkedAt = now
+		return db.cache, nil
+	}
+	records, err := db.read()
 	if err != nil {
 		return nil, err
 	}
-	if len(records) == 0 {
-		return nil, ErrRecordNotFound
-	}
-	return records, nil
+	db.cache = newRecordCache(records, stats, now)
+	return db.cache, nil
 }
 
-func (db *DatabaseImpl) Get(ctx context.Context, name string) (*Record, error) {
+// snapshot returns the cache, which is never modified, taking the write lock only to reload it.
+func (db *DatabaseImpl) snapshot() (*recordCache, error) {
 	db.mux.RLock()
-	defer db.mux.RUnlock()
+	c, ok := db.cached()
+	db.mux.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+	return db.load()
+}
 
+func (db *DatabaseImpl) stat() []os.FileInfo {
+	if db.journal == nil {
+		return statFiles(db.dbFile)
+	}
+	return statFiles(db.dbFile, db.journal)
+}
+
+// read returns the snapshot with the journal replayed on top of it.
+func (db *DatabaseImpl) read() ([]*Record, error) {
 	records, err := db.dbFile.Read()
 	if err != nil {
 		return nil, err
 	}
-	for _, r := range records {
-		if name == r.Name {
-			return r, nil
+	if db.journal == nil {
+		return records, nil
+	}
+	entries, err := db.journal.Read()
+	if err != nil {
+		return nil, err
+	}
+	return ReplayJournal(records, entries), nil
+}
+
+// write persists the records resulting from entry and replaces the cache with them.
+func (db *DatabaseImpl) write(entry *JournalEntry, records []*Record) error {
+	if err := db.persist(entry, records); err != nil {
+		// the files may be partially written
+		db.cache = nil
+		return err
+	}
+	db.cache = newRecordCache(records, db.stat(), time.Now())
+	return nil
+}
+
+func (db *DatabaseImpl) persist(entry *JournalEntry, records []*Record) error {
+	if db.journal == nil {
+		return db.dbFile.Write(records)
+	}
+	size, err := db.journal.Append(entry)
+	if err != nil {
+		return err
+	}
+	if size < db.compactThreshold {
+		return nil
+	}
+	return db.compact(records)
+}
+
+func (db *DatabaseImpl) compact(records []*Record) error {
+	if err := db.dbFile.Write(records); err != nil {
+		return err
+	}
+	// replaying entries already in the snapshot after a crash is harmless
+	return db.journal.Truncate()
+}
+
+// Compact folds the journal into the snapshot file, if the journal has entries.
+func (db *DatabaseImpl) Compact(ctx context.Context) error {
+	if db.journal == nil {
+		return nil
+	}
+
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	entries, err := db.journal.Read()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	records, err := db.read()
+	if err != nil {
+		return err
+	}
+	if err := db.compact(records); err != nil {
+		db.cache = nil
+		return err
+	}
+	db.cache = newRecordCache(records, db.stat(), time.Now())
+	return nil
+}
+
+// RunCompaction compacts the journal every interval until ctx is canceled.
+func (db *DatabaseImpl) RunCompaction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := db.Compact(ctx); err != nil {
+				slog.Error("compact", slog.Any("error", err))
+			}
 		}
 	}
+}
 
-	return nil, fmt.Errorf("%w, %s", ErrRecordNotFound, name)
+func (db *DatabaseImpl) Scan(ctx context.Context) ([]*Record, error) {
+	c, err := db.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return append([]*Record(nil), c.records...), nil
+}
+
+// Index returns the index of the cached records, rebuilt with the cache.
+func (db *DatabaseImpl) Index(ctx context.Context) (*RecordIndex, error) {
+	c, err := db.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return c.recordIndex, nil
+}
+
+func (db *DatabaseImpl) Get(ctx context.Context, namespace, name string) (*Record, error) {
+	c, err := db.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := c.index[recordKey(namespace, name)]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
 }
 
 func (db *DatabaseImpl) Put(ctx context.Context, record *Record) error {
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	records, err := db.dbFile.Read()
+	c, err := db.load()
 	if err != nil {
 		return err
 	}
 
-	var found *Record
-	for _, r := range records {
-		if record.Name == r.Name {
-			found = r
-			break
+	// cached records are shared with readers, so build a new list
+	var (
+		put     = *record
+		records = make([]*Record, 0, len(c.records)+1)
+		found   bool
+	)
+	for _, r := range c.records {
+		if r.key() == put.key() {
+			records = append(records, &put)
+			found = true
+			continue
 		}
+		records = append(records, r)
 	}
-
-	if found != nil {
-		found.To = record.To
-	} else {
-		records = append(records, record)
+	if !found {
+		records = append(records, &put)
 	}
-	return db.dbFile.Write(records)
+
+	return db.write(&JournalEntry{
+		Op:     JournalOpPut,
+		Record: &put,
+	}, records)
 }
 
-func (db *DatabaseImpl) Delete(ctx context.Context, name string) error {
+func (db *DatabaseImpl) Delete(ctx context.Context, namespace, name string) error {
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	records, err := db.dbFile.Read()
+	c, err := db.load()
 	if err != nil {
 		return err
 	}
@@ -110,8 +286,8 @@ func (db *DatabaseImpl) Delete(ctx context.Context, name string) error {
 		rs    []*Record
 		found bool
 	)
-	for _, r := range records {
-		if r.Name == name {
+	for _, r := range c.records {
+		if r.key() == recordKey(namespace, name) {
 			found = true
 			continue
 		}
@@ -119,9 +295,39 @@ func (db *DatabaseImpl) Delete(ctx context.Context, name string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("%w, %s", ErrRecordNotFound, name)
+		return fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
+	}
+	return db.write(&JournalEntry{
+		Op:        JournalOpDelete,
+		Namespace: namespace,
+		Name:      name,
+	}, rs)
+}
+
+func (db *DatabaseImpl) Batch(ctx context.Context, ops []*BatchOperation) error {
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	c, err := db.load()
+	if err != nil {
+		return err
 	}
-	return db.dbFile.Write(rs)
+	records, err := applyBatch(c.records, ops)
+	if err != nil {
+		return err
+	}
+	entry := &JournalEntry{
+		Op: JournalOpBatch,
+	}
+	for _, o := range ops {
+		if o.Op == BatchOpPut {
+			put := *o.Record
+			entry.Entries = append(entry.Entries, &JournalEntry{Op: JournalOpPut, Record: &put})
+			continue
+		}
+		entry.Entries = append(entry.Entries, &JournalEntry{Op: JournalOpDelete, Namespace: o.Namespace, Name: o.Name})
+	}
+	return db.write(entry, records)
 }
 
 var (
@@ -155,12 +361,55 @@ func (f *databaseFile) Write(records []*Record) error {
 	if err != nil {
 		return fmt.Errorf("%w, marshal", ErrWriteDatabase)
 	}
-	if err := os.WriteFile(f.filename, b, 0666); err != nil {
-		return fmt.Errorf("%w, write", ErrWriteDatabase)
+	if err := writeFileAtomic(f.filename, b); err != nil {
+		return fmt.Errorf("%w, write %v", ErrWriteDatabase, err)
 	}
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file, fsyncs it and renames it to filename.
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	f, err := os.CreateTemp(dir, filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	defer os.Remove(tmpName) // no-op after a successful rename
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (f *databaseFile) Stat() (os.FileInfo, error) {
+	return os.Stat(f.filename)
+}
+
 func (f *databaseFile) Read() ([]*Record, error) {
 	f.mux.RLock()
 	defer f.mux.RUnlock()
@@ -0,0 +1,290 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+	"experimental-terraform-redirect-store/api/databasetest"
+)
+
+// newDatabaseFile returns an empty database file in a temporary directory.
+func newDatabaseFile(t *testing.T) (string, api.DatabaseFile) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "api.db")
+	if err := os.WriteFile(filename, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename, api.NewDatabaseFile(filename)
+}
+
+func TestDatabaseImpl(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) api.Database {
+		_, dbFile := newDatabaseFile(t)
+		return api.NewDatabaseImpl(dbFile)
+	})
+}
+
+func TestJournaledDatabaseImpl(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) api.Database {
+		filename, dbFile := newDatabaseFile(t)
+		journal, err := api.NewJournalFile(filename + ".journal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return api.NewJournaledDatabaseImpl(dbFile, journal, 2)
+	})
+}
+
+func TestJournaledDatabaseImplReplayBatch(t *testing.T) {
+	ctx := context.Background()
+	filename, dbFile := newDatabaseFile(t)
+	open := func() api.Database {
+		journal, err := api.NewJournalFile(filename + ".journal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return api.NewJournaledDatabaseImpl(dbFile, journal, 100)
+	}
+	if err := open().Batch(ctx, []*api.BatchOperation{
+		{Op: api.BatchOpPut, Record: &api.Record{Name: "a", To: "https://example.com/a"}},
+		{Op: api.BatchOpPut, Record: &api.Record{Name: "b", To: "https://example.com/b"}},
+		{Op: api.BatchOpDelete, Name: "a"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := open().Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "b" {
+		t.Errorf("want b, got %v", records)
+	}
+}
+
+func TestDatabaseFile(t *testing.T) {
+	databasetest.RunFile(t, func(t *testing.T) api.DatabaseFile {
+		_, dbFile := newDatabaseFile(t)
+		return dbFile
+	})
+}
+
+func TestJournalFileDropsPartialEntry(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "api.db.journal")
+	if err := os.WriteFile(filename, []byte(`{"op":"put","record":{"name":"a","to":"https://example.com/a"}}`+"\n"+`{"op":"put","rec`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := api.NewJournalFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := journal.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Record.Name != "a" {
+		t.Fatalf("want the entry of a, got %v", entries)
+	}
+	if n, err := journal.Append(&api.JournalEntry{Op: api.JournalOpDelete, Name: "a"}); err != nil || n != 2 {
+		t.Fatalf("want 2 entries, got %d, %v", n, err)
+	}
+	entries, err = journal.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Op != api.JournalOpDelete {
+		t.Errorf("want the delete appended, got %v", entries)
+	}
+}
+
+func TestJournaledDatabaseImplReplayOnOpen(t *testing.T) {
+	ctx := context.Background()
+	filename, dbFile := newDatabaseFile(t)
+	if err := dbFile.Write([]*api.Record{
+		{Name: "a", To: "https://example.com/a"},
+		{Name: "b", To: "https://example.com/b"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// the journal left by the previous run, torn by a crash
+	if err := os.WriteFile(filename+".journal", []byte(
+		`{"op":"put","record":{"name":"c","to":"https://example.com/c"}}`+"\n"+
+			`{"op":"delete","name":"a"}`+"\n"+
+			`{"op":"put","record":{"name":"b","to`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	journal, err := api.NewJournalFile(filename + ".journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := api.NewJournaledDatabaseImpl(dbFile, journal, 100)
+	if err := db.Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []*api.Record{
+		{Name: "b", To: "https://example.com/b"},
+		{Name: "c", To: "https://example.com/c"},
+	}
+	got, err := db.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	snapshot, err := dbFile.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, snapshot) {
+		t.Errorf("want the snapshot %v, got %v", want, snapshot)
+	}
+	if entries, err := journal.Read(); err != nil || len(entries) != 0 {
+		t.Errorf("want the journal truncated, got %v, %v", entries, err)
+	}
+}
+
+func TestJournaledDatabaseImplCompactThreshold(t *testing.T) {
+	ctx := context.Background()
+	filename, dbFile := newDatabaseFile(t)
+	journal, err := api.NewJournalFile(filename + ".journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := api.NewJournaledDatabaseImpl(dbFile, journal, 3)
+	put := func(name string) {
+		t.Helper()
+		if err := db.Put(ctx, &api.Record{Name: name, To: "https://example.com/" + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertFiles := func(snapshot, entries int) {
+		t.Helper()
+		records, err := dbFile.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != snapshot {
+			t.Errorf("want %d records in the snapshot, got %d", snapshot, len(records))
+		}
+		es, err := journal.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(es) != entries {
+			t.Errorf("want %d journal entries, got %d", entries, len(es))
+		}
+	}
+
+	put("a")
+	put("b")
+	assertFiles(0, 2)
+	put("c")
+	assertFiles(3, 0)
+	put("d")
+	assertFiles(3, 1)
+}
+
+func TestJournaledDatabaseImplRunCompaction(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	filename, dbFile := newDatabaseFile(t)
+	journal, err := api.NewJournalFile(filename + ".journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := api.NewJournaledDatabaseImpl(dbFile, journal, 100)
+	if err := db.Put(ctx, &api.Record{Name: "a", To: "https://example.com/a"}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		db.RunCompaction(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		records, err := dbFile.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("want the journal compacted")
+		}
+	}
+	if entries, err := journal.Read(); err != nil || len(entries) != 0 {
+		t.Errorf("want the journal truncated, got %v, %v", entries, err)
+	}
+}
+
+// countingDatabaseFile counts the reads of the file.
+type countingDatabaseFile struct {
+	api.DatabaseFile
+	filename string
+	reads    atomic.Int32
+}
+
+func (f *countingDatabaseFile) Read() ([]*api.Record, error) {
+	f.reads.Add(1)
+	return f.DatabaseFile.Read()
+}
+
+func (f *countingDatabaseFile) Stat() (os.FileInfo, error) {
+	return os.Stat(f.filename)
+}
+
+func TestDatabaseImplReloadsExternalChanges(t *testing.T) {
+	ctx := context.Background()
+	filename, dbFile := newDatabaseFile(t)
+	file := &countingDatabaseFile{DatabaseFile: dbFile, filename: filename}
+	db := api.NewDatabaseImpl(file)
+	db.SetCacheCheckInterval(10 * time.Millisecond)
+	if err := db.Put(ctx, &api.Record{Name: "a", To: "https://example.com/a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reads := file.reads.Load()
+	time.Sleep(20 * time.Millisecond)
+	if _, err := db.Get(ctx, api.DefaultNamespace, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := file.reads.Load(); got != reads {
+		t.Errorf("want no reload of the unchanged file, got %d reads", got-reads)
+	}
+
+	// written by another process
+	if err := api.NewDatabaseFile(filename).Write([]*api.Record{
+		{Name: "bb", To: "https://example.com/bb"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := db.Get(ctx, api.DefaultNamespace, "a"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Errorf("want a removed, got %v", err)
+	}
+	if _, err := db.Get(ctx, api.DefaultNamespace, "bb"); err != nil {
+		t.Errorf("want bb, got %v", err)
+	}
+	records, err := db.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "bb" {
+		t.Errorf("want bb, got %v", records)
+	}
+}
@@ -0,0 +1,457 @@
+// Package databasetest provides a conformance test suite for api.Database implementations.
+package databasetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+// Run runs the conformance tests against the empty databases built by newDB.
+func Run(t *testing.T, newDB func(t *testing.T) api.Database) {
+	t.Run("put and get", func(t *testing.T) {
+		testPutGet(t, newDB(t))
+	})
+	t.Run("put upserts", func(t *testing.T) {
+		testUpsert(t, newDB(t))
+	})
+	t.Run("get missing", func(t *testing.T) {
+		testGetMissing(t, newDB(t))
+	})
+	t.Run("delete", func(t *testing.T) {
+		testDelete(t, newDB(t))
+	})
+	t.Run("delete missing", func(t *testing.T) {
+		testDeleteMissing(t, newDB(t))
+	})
+	t.Run("scan empty", func(t *testing.T) {
+		testScanEmpty(t, newDB(t))
+	})
+	t.Run("scan order", func(t *testing.T) {
+		testScanOrder(t, newDB(t))
+	})
+	t.Run("concurrent writers", func(t *testing.T) {
+		testConcurrentWriters(t, newDB(t))
+	})
+	t.Run("namespaces", func(t *testing.T) {
+		testNamespaces(t, newDB(t))
+	})
+	t.Run("batch", func(t *testing.T) {
+		testBatch(t, newDB(t))
+	})
+	t.Run("batch is atomic", func(t *testing.T) {
+		testBatchAtomic(t, newDB(t))
+	})
+	t.Run("index", func(t *testing.T) {
+		testIndex(t, newDB(t))
+	})
+}
+
+func testPutGet(t *testing.T, db api.Database) {
+	var (
+		ctx       = context.Background()
+		notBefore = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		expiresAt = time.Date(2024, 2, 1, 9, 0, 0, 0, time.FixedZone("", 9*60*60))
+		want      = &api.Record{
+			Name:       "name",
+			To:         "https://example.com/",
+			StatusCode: 302,
+			Match:      api.MatchPrefix,
+			Query:      api.QueryMergeRequest,
+			NotBefore:  &notBefore,
+			ExpiresAt:  &expiresAt,
+			Version:    3,
+		}
+	)
+	mustPut(t, db, want)
+	got, err := db.Get(ctx, api.DefaultNamespace, want.Name)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertRecord(t, want, got)
+}
+
+func testUpsert(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	mustPut(t, db, &api.Record{
+		Name: "name",
+		To:   "https://example.com/old",
+	})
+	want := &api.Record{
+		Name: "name",
+		To:   "https://example.com/new",
+	}
+	mustPut(t, db, want)
+
+	got, err := db.Get(ctx, api.DefaultNamespace, want.Name)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertRecord(t, want, got)
+
+	records, err := db.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Scan: want 1 record, got %d", len(records))
+	}
+	assertRecord(t, want, records[0])
+}
+
+func testGetMissing(t *testing.T, db api.Database) {
+	mustPut(t, db, &api.Record{
+		Name: "name",
+		To:   "https://example.com/",
+	})
+	if _, err := db.Get(context.Background(), api.DefaultNamespace, "missing"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Get: want ErrRecordNotFound, got %v", err)
+	}
+}
+
+func testDelete(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	mustPut(t, db, &api.Record{
+		Name: "name",
+		To:   "https://example.com/",
+	})
+	if err := db.Delete(ctx, api.DefaultNamespace, "name"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := db.Get(ctx, api.DefaultNamespace, "name"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Get after Delete: want ErrRecordNotFound, got %v", err)
+	}
+}
+
+func testDeleteMissing(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	if err := db.Delete(ctx, api.DefaultNamespace, "missing"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Delete on empty: want ErrRecordNotFound, got %v", err)
+	}
+	mustPut(t, db, &api.Record{
+		Name: "name",
+		To:   "https://example.com/",
+	})
+	if err := db.Delete(ctx, api.DefaultNamespace, "missing"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Delete: want ErrRecordNotFound, got %v", err)
+	}
+	if _, err := db.Get(ctx, api.DefaultNamespace, "name"); err != nil {
+		t.Fatalf("Get after failed Delete: %v", err)
+	}
+}
+
+func testScanEmpty(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	if _, err := db.Scan(ctx); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Scan on empty: want ErrRecordNotFound, got %v", err)
+	}
+	mustPut(t, db, &api.Record{
+		Name: "name",
+		To:   "https://example.com/",
+	})
+	if err := db.Delete(ctx, api.DefaultNamespace, "name"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := db.Scan(ctx); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Scan after deleting all: want ErrRecordNotFound, got %v", err)
+	}
+}
+
+// testScanOrder checks that writes do not move the other records in Scan.
+func testScanOrder(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	for _, name := range []string{"c", "a", "d", "b"} {
+		mustPut(t, db, &api.Record{
+			Name: name,
+			To:   "https://example.com/" + name,
+		})
+	}
+	order := scanNames(t, db)
+	if len(order) != 4 {
+		t.Fatalf("Scan: want 4 records, got %v", order)
+	}
+	assertNames(t, order, scanNames(t, db))
+
+	mustPut(t, db, &api.Record{
+		Name: "d",
+		To:   "https://example.com/updated",
+	})
+	assertNames(t, order, scanNames(t, db))
+
+	if err := db.Delete(ctx, api.DefaultNamespace, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	var want []string
+	for _, name := range order {
+		if name != "a" {
+			want = append(want, name)
+		}
+	}
+	assertNames(t, want, scanNames(t, db))
+}
+
+func testConcurrentWriters(t *testing.T, db api.Database) {
+	const writers = 8
+	var (
+		ctx  = context.Background()
+		wg   sync.WaitGroup
+		errC = make(chan error, writers*2)
+	)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := db.Put(ctx, &api.Record{
+				Name: fmt.Sprintf("name%d", i),
+				To:   fmt.Sprintf("https://example.com/%d", i),
+			}); err != nil {
+				errC <- err
+			}
+			if err := db.Put(ctx, &api.Record{
+				Name: "shared",
+				To:   fmt.Sprintf("https://example.com/%d", i),
+			}); err != nil {
+				errC <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errC)
+	for err := range errC {
+		t.Errorf("Put: %v", err)
+	}
+
+	records, err := db.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(records) != writers+1 {
+		t.Fatalf("Scan: want %d records, got %d", writers+1, len(records))
+	}
+	for i := 0; i < writers; i++ {
+		got, err := db.Get(ctx, api.DefaultNamespace, fmt.Sprintf("name%d", i))
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		assertRecord(t, &api.Record{
+			Name: fmt.Sprintf("name%d", i),
+			To:   fmt.Sprintf("https://example.com/%d", i),
+		}, got)
+	}
+}
+
+// testNamespaces checks that the records of the same name in the namespaces are independent.
+func testNamespaces(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	var (
+		inDefault = &api.Record{
+			Name: "name",
+			To:   "https://example.com/default",
+		}
+		inTeam = &api.Record{
+			Namespace: "team",
+			Name:      "name",
+			To:        "https://example.com/team",
+		}
+	)
+	mustPut(t, db, inDefault)
+	mustPut(t, db, inTeam)
+
+	got, err := db.Get(ctx, api.DefaultNamespace, "name")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertRecord(t, inDefault, got)
+	got, err = db.Get(ctx, "team", "name")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertRecord(t, inTeam, got)
+	if _, err := db.Get(ctx, "other", "name"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Get in other namespace: want ErrRecordNotFound, got %v", err)
+	}
+
+	records, err := db.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Scan: want 2 records, got %d", len(records))
+	}
+
+	if err := db.Delete(ctx, "team", "name"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	got, err = db.Get(ctx, api.DefaultNamespace, "name")
+	if err != nil {
+		t.Fatalf("Get after Delete in other namespace: %v", err)
+	}
+	assertRecord(t, inDefault, got)
+}
+
+func testBatch(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	mustPut(t, db, &api.Record{Name: "old", To: "https://example.com/old"})
+	mustPut(t, db, &api.Record{Name: "changed", To: "https://example.com/before"})
+
+	changed := &api.Record{Name: "changed", To: "https://example.com/after"}
+	if err := db.Batch(ctx, []*api.BatchOperation{
+		{Op: api.BatchOpPut, Record: &api.Record{Name: "new", To: "https://example.com/new"}},
+		{Op: api.BatchOpDelete, Name: "old"},
+		{Op: api.BatchOpPut, Record: changed},
+		{Op: api.BatchOpPut, Record: &api.Record{Namespace: "team", Name: "new", To: "https://example.com/team"}},
+		{Op: api.BatchOpDelete, Namespace: "team", Name: "new"},
+	}); err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	assertNames(t, []string{"changed", "new"}, scanNames(t, db))
+	got, err := db.Get(ctx, api.DefaultNamespace, "changed")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertRecord(t, changed, got)
+}
+
+func testBatchAtomic(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	mustPut(t, db, &api.Record{Name: "kept", To: "https://example.com/kept"})
+
+	err := db.Batch(ctx, []*api.BatchOperation{
+		{Op: api.BatchOpPut, Record: &api.Record{Name: "new", To: "https://example.com/new"}},
+		{Op: api.BatchOpDelete, Name: "kept"},
+		{Op: api.BatchOpDelete, Name: "missing"},
+	})
+	if !errors.Is(err, api.ErrRecordNotFound) {
+		t.Fatalf("Batch: want ErrRecordNotFound, got %v", err)
+	}
+	assertNames(t, []string{"kept"}, scanNames(t, db))
+}
+
+func testIndex(t *testing.T, db api.Database) {
+	ctx := context.Background()
+	matchPattern := func(namespace, name string) *api.Record {
+		t.Helper()
+		index, err := db.Index(ctx)
+		if err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+		r, _, _ := index.MatchPattern(namespace, name)
+		return r
+	}
+	if r := matchPattern(api.DefaultNamespace, "gh/a/b"); r != nil {
+		t.Fatalf("empty: want no match, got %v", r)
+	}
+
+	mustPut(t, db, &api.Record{Name: "gh/{org}/{repo}", To: "https://github.com/{org}/{repo}"})
+	mustPut(t, db, &api.Record{Namespace: "team", Name: "docs", To: "https://example.com/"})
+	if r := matchPattern(api.DefaultNamespace, "gh/a/b"); r == nil || r.Name != "gh/{org}/{repo}" {
+		t.Errorf("after put: want gh/{org}/{repo}, got %v", r)
+	}
+	if r := matchPattern("team", "gh/a/b"); r != nil {
+		t.Errorf("other namespace: want no match, got %v", r)
+	}
+	index, err := db.Index(ctx)
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if !index.HasNamespace("team") || index.HasNamespace("ops") {
+		t.Errorf("want namespace team only, got team %v, ops %v", index.HasNamespace("team"), index.HasNamespace("ops"))
+	}
+
+	if err := db.Delete(ctx, api.DefaultNamespace, "gh/{org}/{repo}"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if r := matchPattern(api.DefaultNamespace, "gh/a/b"); r != nil {
+		t.Errorf("after delete: want no match, got %v", r)
+	}
+
+	if err := db.Batch(ctx, []*api.BatchOperation{
+		{Op: api.BatchOpPut, Record: &api.Record{Name: "gl/{org}", To: "https://gitlab.com/{org}"}},
+	}); err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if r := matchPattern(api.DefaultNamespace, "gl/a"); r == nil {
+		t.Errorf("after batch: want gl/{org}, got nil")
+	}
+
+	mustPut(t, db, &api.Record{Name: "docs", To: "https://example.com/docs", Match: api.MatchPrefix})
+	mustPut(t, db, &api.Record{Name: "docs/go", To: "https://go.dev/doc", Match: api.MatchPrefix})
+	mustPut(t, db, &api.Record{Name: "docs/go/spec", To: "https://go.dev/ref/spec"})
+	if index, err = db.Index(ctx); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	for _, tc := range []struct {
+		name   string
+		record string
+		rest   string
+	}{
+		{name: "docs/go/spec/types", record: "docs/go", rest: "spec/types"},
+		{name: "docs/python", record: "docs", rest: "python"},
+		{name: "docs"},
+		{name: "blog/docs"},
+	} {
+		r, rest, ok := index.MatchPrefix(api.DefaultNamespace, tc.name)
+		if tc.record == "" {
+			if ok {
+				t.Errorf("prefix %s: want no match, got %v", tc.name, r)
+			}
+			continue
+		}
+		if !ok || r.Name != tc.record || rest != tc.rest {
+			t.Errorf("prefix %s: want %s and %s, got %v and %s", tc.name, tc.record, tc.rest, r, rest)
+		}
+	}
+}
+
+func scanNames(t *testing.T, db api.Database) []string {
+	t.Helper()
+	records, err := db.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	names := make([]string, len(records))
+	for i, r := range records {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func assertNames(t *testing.T, want, got []string) {
+	t.Helper()
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Scan order: want %v, got %v", want, got)
+	}
+}
+
+func mustPut(t *testing.T, db api.Database, record *api.Record) {
+	t.Helper()
+	if err := db.Put(context.Background(), record); err != nil {
+		t.Fatalf("Put %v: %v", record, err)
+	}
+}
+
+// assertRecord compares the records as JSON since the backends lose the monotonic clock.
+func assertRecord(t *testing.T, want, got *api.Record) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("want %v, got nil", want)
+	}
+	w, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(w) != string(g) {
+		t.Errorf("want %s, got %s", w, g)
+	}
+}
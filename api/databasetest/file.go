@@ -0,0 +1,75 @@
+package databasetest
+
+import (
+	"reflect"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+// RunFile runs the conformance tests against the empty database files built by newFile.
+func RunFile(t *testing.T, newFile func(t *testing.T) api.DatabaseFile) {
+	t.Run("read empty", func(t *testing.T) {
+		records, err := newFile(t).Read()
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if len(records) != 0 {
+			t.Fatalf("Read: want no records, got %v", records)
+		}
+	})
+	t.Run("write and read", func(t *testing.T) {
+		f := newFile(t)
+		want := []*api.Record{
+			{Name: "b", To: "https://example.com/b"},
+			{Name: "a", To: "https://example.com/a", StatusCode: 307},
+		}
+		mustWrite(t, f, want)
+		assertRecords(t, want, mustRead(t, f))
+	})
+	t.Run("write overwrites", func(t *testing.T) {
+		f := newFile(t)
+		mustWrite(t, f, []*api.Record{
+			{Name: "a", To: "https://example.com/a"},
+			{Name: "b", To: "https://example.com/b"},
+		})
+		want := []*api.Record{
+			{Name: "c", To: "https://example.com/c"},
+		}
+		mustWrite(t, f, want)
+		assertRecords(t, want, mustRead(t, f))
+	})
+	t.Run("write empty", func(t *testing.T) {
+		f := newFile(t)
+		mustWrite(t, f, []*api.Record{
+			{Name: "a", To: "https://example.com/a"},
+		})
+		mustWrite(t, f, nil)
+		if records := mustRead(t, f); len(records) != 0 {
+			t.Fatalf("Read: want no records, got %v", records)
+		}
+	})
+}
+
+func mustWrite(t *testing.T, f api.DatabaseFile, records []*api.Record) {
+	t.Helper()
+	if err := f.Write(records); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+func mustRead(t *testing.T, f api.DatabaseFile) []*api.Record {
+	t.Helper()
+	records, err := f.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return records
+}
+
+func assertRecords(t *testing.T, want, got []*api.Record) {
+	t.Helper()
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidDomain  = errors.New("InvalidDomain")
+	ErrDomainNotFound = errors.New("DomainNotFound")
+)
+
+// Domain maps the host of the redirects to the namespace of the records.
+type Domain struct {
+	Host      string `json:"host"`
+	Namespace string `json:"namespace"`
+}
+
+// NormalizeHost returns the host without the port and the trailing dot in lower case.
+func NormalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func (d *Domain) Validate() error {
+	if d.Host == "" {
+		return fmt.Errorf("%w, no host", ErrInvalidDomain)
+	}
+	if NormalizeHost(d.Host) != d.Host {
+		return fmt.Errorf("%w, host %s must be lower case without port", ErrInvalidDomain, d.Host)
+	}
+	if strings.ContainsAny(d.Host, "/ ") {
+		return fmt.Errorf("%w, host %s contains / or space", ErrInvalidDomain, d.Host)
+	}
+	if err := ValidateNamespace(d.Namespace); err != nil {
+		return fmt.Errorf("%w, %v", ErrInvalidDomain, err)
+	}
+	return nil
+}
+
+// DomainStore persists the domains.
+type DomainStore interface {
+	// Scan returns the domains sorted by host.
+	Scan(ctx context.Context) ([]*Domain, error)
+	Get(ctx context.Context, host string) (*Domain, error)
+	Put(ctx context.Context, domain *Domain) error
+	Delete(ctx context.Context, host string) error
+}
+
+// NewDomainMemory returns a DomainStore that does not persist the domains.
+func NewDomainMemory() DomainStore {
+	return newDomainMemory(nil)
+}
+
+func newDomainMemory(domains []*Domain) *domainMemory {
+	m := &domainMemory{
+		domains: map[string]Domain{},
+	}
+	for _, d := range domains {
+		m.domains[d.Host] = *d
+	}
+	return m
+}
+
+type domainMemory struct {
+	domains map[string]Domain
+	mux     sync.RWMutex
+}
+
+func (m *domainMemory) Scan(_ context.Context) ([]*Domain, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	return m.list(), nil
+}
+
+func (m *domainMemory) list() []*Domain {
+	domains := make([]*Domain, 0, len(m.domains))
+	for _, d := range m.domains {
+		d := d
+		domains = append(domains, &d)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Host < domains[j].Host
+	})
+	return domains
+}
+
+func (m *domainMemory) Get(_ context.Context, host string) (*Domain, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if d, ok := m.domains[host]; ok {
+		return &d, nil
+	}
+	return nil, fmt.Errorf("%w, %s", ErrDomainNotFound, host)
+}
+
+func (m *domainMemory) Put(_ context.Context, domain *Domain) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.domains[domain.Host] = *domain
+	return nil
+}
+
+func (m *domainMemory) Delete(_ context.Context, host string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if _, ok := m.domains[host]; !ok {
+		return fmt.Errorf("%w, %s", ErrDomainNotFound, host)
+	}
+	delete(m.domains, host)
+	return nil
+}
+
+// NewDomainFile returns a DomainStore on the JSON file.
+func NewDomainFile(filename string) (DomainStore, error) {
+	var domains []*Domain
+	b, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("%w, read domains %v", ErrConnectDatabase, err)
+	default:
+		if err := json.Unmarshal(b, &domains); err != nil {
+			return nil, fmt.Errorf("%w, unmarshal domains %v", ErrConnectDatabase, err)
+		}
+	}
+	return &domainFile{
+		domainMemory: newDomainMemory(domains),
+		filename:     filename,
+	}, nil
+}
+
+type domainFile struct {
+	*domainMemory
+	filename string
+}
+
+func (f *domainFile) Put(_ context.Context, domain *Domain) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	prev, found := f.domains[domain.Host]
+	f.domains[domain.Host] = *domain
+	if err := f.write(); err != nil {
+		if found {
+			f.domains[domain.Host] = prev
+		} else {
+			delete(f.domains, domain.Host)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *domainFile) Delete(_ context.Context, host string) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	prev, found := f.domains[host]
+	if !found {
+		return fmt.Errorf("%w, %s", ErrDomainNotFound, host)
+	}
+	delete(f.domains, host)
+	if err := f.write(); err != nil {
+		f.domains[host] = prev
+		return err
+	}
+	return nil
+}
+
+func (f *domainFile) write() error {
+	b, err := json.Marshal(f.list())
+	if err != nil {
+		return fmt.Errorf("%w, marshal domains", ErrWriteDatabase)
+	}
+	if err := writeFileAtomic(f.filename, b); err != nil {
+		return fmt.Errorf("%w, write domains %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	for _, tc := range []struct {
+		host string
+		want string
+	}{
+		{host: "go.example.com", want: "go.example.com"},
+		{host: "Go.Example.com", want: "go.example.com"},
+		{host: "go.example.com:8080", want: "go.example.com"},
+		{host: "go.example.com.", want: "go.example.com"},
+		{host: "[::1]:8080", want: "::1"},
+	} {
+		t.Run(tc.host, func(t *testing.T) {
+			if got := api.NormalizeHost(tc.host); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServerImplRedirectDomain(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "x", To: "https://example.com/default"},
+		&api.Record{Namespace: "go", Name: "x", To: "https://example.com/go"},
+		&api.Record{Namespace: "links", Name: "x", To: "https://example.com/links"},
+	)
+	ctx := context.Background()
+	for _, d := range []*api.Domain{
+		{Host: "go.example.com", Namespace: "go"},
+		{Host: "links.example.com", Namespace: "links"},
+		{Host: "default.example.com", Namespace: api.DefaultNamespace},
+	} {
+		if _, err := server.PutDomain(ctx, &api.PutDomainRequest{Domain: d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		title string
+		host  string
+		name  string
+		want  string
+	}{
+		{title: "go", host: "go.example.com", name: "x", want: "https://example.com/go"},
+		{title: "links", host: "links.example.com:8030", name: "x", want: "https://example.com/links"},
+		{title: "default", host: "default.example.com", name: "x", want: "https://example.com/default"},
+		{title: "no namespace in path of domain", host: "default.example.com", name: "go/x"},
+		{title: "unknown host", host: "other.example.com", name: "x", want: "https://example.com/default"},
+		{title: "namespace in path of unknown host", host: "other.example.com", name: "go/x", want: "https://example.com/go"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			got, err := server.Redirect(ctx, &api.RedirectRequest{
+				Host: tc.host,
+				Name: tc.name,
+			})
+			if tc.want == "" {
+				if !errors.Is(err, api.ErrRecordNotFound) {
+					t.Fatalf("want ErrRecordNotFound, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.To != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got.To)
+			}
+		})
+	}
+
+	t.Run("suggestions of domain are not qualified", func(t *testing.T) {
+		if _, err := server.PutFallback(ctx, &api.PutFallbackRequest{
+			Fallback: &api.Fallback{Mode: api.FallbackSuggest},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := server.Redirect(ctx, &api.RedirectRequest{Host: "go.example.com", Name: "y"})
+		if want := []string{"x"}; !reflect.DeepEqual(want, got.Suggestions) {
+			t.Errorf("want %v, got %v", want, got.Suggestions)
+		}
+		got, _ = server.Redirect(ctx, &api.RedirectRequest{Name: "go/y"})
+		if want := []string{"go/x"}; !reflect.DeepEqual(want, got.Suggestions) {
+			t.Errorf("want %v, got %v", want, got.Suggestions)
+		}
+	})
+}
+
+func TestServerImplPutDomain(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		title  string
+		domain *api.Domain
+	}{
+		{title: "no domain"},
+		{title: "no host", domain: &api.Domain{Namespace: "go"}},
+		{title: "upper case", domain: &api.Domain{Host: "Go.example.com"}},
+		{title: "port", domain: &api.Domain{Host: "go.example.com:8080"}},
+		{title: "invalid namespace", domain: &api.Domain{Host: "go.example.com", Namespace: "Go"}},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			if _, err := server.PutDomain(ctx, &api.PutDomainRequest{Domain: tc.domain}); !errors.Is(err, api.ErrInvalidDomain) {
+				t.Errorf("want ErrInvalidDomain, got %v", err)
+			}
+		})
+	}
+	if _, err := server.DeleteDomain(ctx, &api.DeleteDomainRequest{Host: "go.example.com"}); !errors.Is(err, api.ErrDomainNotFound) {
+		t.Errorf("want ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestDomainFile(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		filename = filepath.Join(t.TempDir(), "domains.json")
+	)
+	store, err := api.NewDomainFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []*api.Domain{
+		{Host: "links.example.com", Namespace: "links"},
+		{Host: "go.example.com", Namespace: "go"},
+		{Host: "old.example.com", Namespace: "old"},
+	} {
+		if err := store.Put(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Delete(ctx, "old.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := api.NewDomainFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*api.Domain{
+		{Host: "go.example.com", Namespace: "go"},
+		{Host: "links.example.com", Namespace: "links"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestRedirectHandlerHost(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "x", To: "https://example.com/default"},
+		&api.Record{Namespace: "go", Name: "x", To: "https://example.com/go"},
+	)
+	if _, err := server.PutDomain(context.Background(), &api.PutDomainRequest{
+		Domain: &api.Domain{Host: "go.example.com", Namespace: "go"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := api.RedirectHandler(server, "/c/")
+	for host, want := range map[string]string{
+		"go.example.com":    "https://example.com/go",
+		"links.example.com": "https://example.com/default",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "http://"+host+"/c/x", nil)
+		w := httptest.NewRecorder()
+		h(w, r)
+		if got := w.Header().Get("Location"); got != want {
+			t.Errorf("%s: want %s, got %s", host, want, got)
+		}
+	}
+}
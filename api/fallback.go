@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidFallback = errors.New("InvalidFallback")
+)
+
+// Fallback is how to respond to the redirects of unknown names.
+type Fallback struct {
+	Mode FallbackMode `json:"mode"`
+	// To is the redirect-to of FallbackRedirect.
+	To string `json:"to,omitempty"`
+	// Record is the name of the fallback records of FallbackRecord.
+	Record string `json:"record,omitempty"`
+	// Suggestions is the max number of names FallbackSuggest lists, DefaultSuggestions if zero.
+	Suggestions int `json:"suggestions,omitempty"`
+}
+
+type FallbackMode string
+
+const (
+	// FallbackNone responds 404 Not Found.
+	FallbackNone FallbackMode = "none"
+	// FallbackRedirect redirects to a default URL.
+	FallbackRedirect FallbackMode = "redirect"
+	// FallbackSuggest responds 404 Not Found with a page listing the closest names.
+	FallbackSuggest FallbackMode = "suggest"
+	// FallbackRecord forwards docs/unknown to docs/_fallback or _fallback if Record is _fallback.
+	FallbackRecord FallbackMode = "record"
+)
+
+// FallbackModes are the available fallback modes.
+var FallbackModes = []FallbackMode{
+	FallbackNone,
+	FallbackRedirect,
+	FallbackSuggest,
+	FallbackRecord,
+}
+
+// DefaultSuggestions is the max number of names FallbackSuggest lists by default.
+const DefaultSuggestions = 5
+
+// FallbackStatusCode is temporary because the name may be registered later.
+const FallbackStatusCode = http.StatusFound
+
+func (f *Fallback) Validate() error {
+	switch f.Mode {
+	case FallbackNone, FallbackSuggest:
+	case FallbackRedirect:
+		if err := ValidateTo(f.To); err != nil {
+			return fmt.Errorf("%w, redirect %v", ErrInvalidFallback, err)
+		}
+	case FallbackRecord:
+		if f.Record == "" || strings.Contains(f.Record, "/") {
+			return fmt.Errorf("%w, record requires a record name without /", ErrInvalidFallback)
+		}
+	default:
+		return fmt.Errorf("%w, mode %s is not one of %v", ErrInvalidFallback, f.Mode, FallbackModes)
+	}
+	if f.Suggestions < 0 {
+		return fmt.Errorf("%w, negative suggestions %d", ErrInvalidFallback, f.Suggestions)
+	}
+	return nil
+}
+
+// MaxSuggestions returns the max number of names FallbackSuggest lists.
+func (f *Fallback) MaxSuggestions() int {
+	if f.Suggestions == 0 {
+		return DefaultSuggestions
+	}
+	return f.Suggestions
+}
+
+// FallbackStore persists the fallback.
+type FallbackStore interface {
+	Get(ctx context.Context) (*Fallback, error)
+	Put(ctx context.Context, fallback *Fallback) error
+}
+
+// NewFallbackMemory returns a FallbackStore that does not persist the fallback.
+func NewFallbackMemory(fallback *Fallback) FallbackStore {
+	return &fallbackMemory{
+		fallback: fallback,
+	}
+}
+
+type fallbackMemory struct {
+	fallback *Fallback
+	mux      sync.RWMutex
+}
+
+func (m *fallbackMemory) Get(_ context.Context) (*Fallback, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	f := *m.fallback
+	return &f, nil
+}
+
+func (m *fallbackMemory) Put(_ context.Context, fallback *Fallback) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	f := *fallback
+	m.fallback = &f
+	return nil
+}
+
+// NewFallbackFile returns a FallbackStore on the JSON file, created with initial if it does not exist.
+func NewFallbackFile(filename string, initial *Fallback) (FallbackStore, error) {
+	f := &fallbackFile{
+		filename: filename,
+	}
+	_, err := os.Stat(filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := f.Put(context.Background(), initial); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("%w, stat fallback %v", ErrConnectDatabase, err)
+	}
+	return f, nil
+}
+
+type fallbackFile struct {
+	filename string
+	mux      sync.RWMutex
+}
+
+func (f *fallbackFile) Get(_ context.Context) (*Fallback, error) {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+
+	b, err := os.ReadFile(f.filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w, read fallback", ErrReadDatabase)
+	}
+	var fallback Fallback
+	if err := json.Unmarshal(b, &fallback); err != nil {
+		return nil, fmt.Errorf("%w, unmarshal fallback", ErrReadDatabase)
+	}
+	return &fallback, nil
+}
+
+func (f *fallbackFile) Put(_ context.Context, fallback *Fallback) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	b, err := json.Marshal(fallback)
+	if err != nil {
+		return fmt.Errorf("%w, marshal fallback", ErrWriteDatabase)
+	}
+	if err := writeFileAtomic(f.filename, b); err != nil {
+		return fmt.Errorf("%w, write fallback %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
+
+// suggest returns the names closest to the name by edit distance, at most n.
+func suggest(name string, names []string, n int) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	var (
+		length = utf8.RuneCountInString(name)
+		// names farther than this are unlikely to be typos of the name
+		maxDistance = max(length/2, 2)
+		candidates  []candidate
+	)
+	for _, x := range names {
+		// the difference of the lengths is the least distance
+		if d := utf8.RuneCountInString(x) - length; d > maxDistance || -d > maxDistance {
+			continue
+		}
+		if d := editDistance(name, x); d <= maxDistance {
+			candidates = append(candidates, candidate{
+				name:     x,
+				distance: d,
+			})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var suggestions []string
+	for _, c := range candidates[:min(n, len(candidates))] {
+		suggestions = append(suggestions, c.name)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	var (
+		ra   = []rune(a)
+		rb   = []rune(b)
+		prev = make([]int, len(rb)+1)
+		curr = make([]int, len(rb)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// fallbackNames returns the names of the fallback records for the name, longest prefix first.
+func fallbackNames(name, record string) []string {
+	var names []string
+	for prefix := name; ; {
+		i := strings.LastIndex(prefix, "/")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+		names = append(names, prefix+"/"+record)
+	}
+	return append(names, record)
+}
@@ -0,0 +1,247 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestServerImplRedirectFallback(t *testing.T) {
+	records := []*api.Record{
+		{Name: "docs", To: "https://example.com/docs"},
+		{Name: "dogs", To: "https://example.com/dogs"},
+		{Name: "dots", To: "https://example.com/dots"},
+		{Name: "unrelated", To: "https://example.com/unrelated"},
+		{Name: "_fallback", To: "https://example.com/fallback?a=1", Query: api.QueryPass},
+		{Name: "docs/_fallback", To: "https://example.com/docs/fallback"},
+		{Name: "gh/{org}", To: "https://github.com/{org}"},
+	}
+
+	for _, tc := range []struct {
+		title       string
+		fallback    *api.Fallback
+		name        string
+		query       string
+		want        string
+		statusCode  int
+		suggestions []string
+	}{
+		{
+			title:    "none",
+			fallback: &api.Fallback{Mode: api.FallbackNone},
+			name:     "doc",
+		},
+		{
+			title:      "redirect",
+			fallback:   &api.Fallback{Mode: api.FallbackRedirect, To: "https://example.com/"},
+			name:       "doc",
+			want:       "https://example.com/",
+			statusCode: api.FallbackStatusCode,
+		},
+		{
+			title:       "suggest",
+			fallback:    &api.Fallback{Mode: api.FallbackSuggest},
+			name:        "doc",
+			suggestions: []string{"docs", "dogs", "dots"},
+		},
+		{
+			title:       "suggest at most",
+			fallback:    &api.Fallback{Mode: api.FallbackSuggest, Suggestions: 2},
+			name:        "doc",
+			suggestions: []string{"docs", "dogs"},
+		},
+		{
+			title:    "suggest nothing close",
+			fallback: &api.Fallback{Mode: api.FallbackSuggest},
+			name:     "zzzzzzzz",
+		},
+		{
+			title:      "record under the prefix",
+			fallback:   &api.Fallback{Mode: api.FallbackRecord, Record: "_fallback"},
+			name:       "docs/unknown/deep",
+			want:       "https://example.com/docs/fallback",
+			statusCode: api.DefaultStatusCode,
+		},
+		{
+			title:      "record at the root",
+			fallback:   &api.Fallback{Mode: api.FallbackRecord, Record: "_fallback"},
+			name:       "blog/unknown",
+			query:      "b=2",
+			want:       "https://example.com/fallback?a=1&b=2",
+			statusCode: api.DefaultStatusCode,
+		},
+		{
+			title:    "record missing",
+			fallback: &api.Fallback{Mode: api.FallbackRecord, Record: "_missing"},
+			name:     "docs/unknown",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			server := newTestServer(t, records...)
+			server.SetFallbackStore(api.NewFallbackMemory(tc.fallback))
+
+			res, err := server.Redirect(context.Background(), &api.RedirectRequest{
+				Name:  tc.name,
+				Query: tc.query,
+			})
+			if tc.want == "" {
+				if !errors.Is(err, api.ErrRecordNotFound) {
+					t.Fatalf("want ErrRecordNotFound, got %v", err)
+				}
+				if !reflect.DeepEqual(tc.suggestions, res.Suggestions) {
+					t.Errorf("suggestions: want %v, got %v", tc.suggestions, res.Suggestions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.To != tc.want {
+				t.Errorf("want %s, got %s", tc.want, res.To)
+			}
+			if res.StatusCode != tc.statusCode {
+				t.Errorf("status code: want %d, got %d", tc.statusCode, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestServerImplPutFallback(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	for _, f := range []*api.Fallback{
+		{Mode: "unknown"},
+		{Mode: api.FallbackRedirect},
+		{Mode: api.FallbackRedirect, To: "/docs"},
+		{Mode: api.FallbackRedirect, To: "javascript:alert(1)"},
+		{Mode: api.FallbackRecord},
+		{Mode: api.FallbackRecord, Record: "docs/_fallback"},
+		{Mode: api.FallbackSuggest, Suggestions: -1},
+	} {
+		if _, err := server.PutFallback(ctx, &api.PutFallbackRequest{
+			Fallback: f,
+		}); !errors.Is(err, api.ErrInvalidFallback) {
+			t.Errorf("%+v: want ErrInvalidFallback, got %v", f, err)
+		}
+	}
+
+	want := &api.Fallback{Mode: api.FallbackRedirect, To: "https://example.com/"}
+	if _, err := server.PutFallback(ctx, &api.PutFallbackRequest{
+		Fallback: want,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := server.GetFallback(ctx, &api.GetFallbackRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, res.Fallback) {
+		t.Errorf("want %+v, got %+v", want, res.Fallback)
+	}
+}
+
+func TestFallbackFile(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "fallback.json")
+	initial := &api.Fallback{Mode: api.FallbackSuggest}
+
+	store, err := api.NewFallbackFile(filename, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(initial, got) {
+		t.Errorf("want %+v, got %+v", initial, got)
+	}
+
+	want := &api.Fallback{Mode: api.FallbackRecord, Record: "_fallback"}
+	if err := store.Put(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	// the initial fallback does not overwrite the existing file
+	reopened, err := api.NewFallbackFile(filename, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = reopened.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestRedirectHandlerSuggestions(t *testing.T) {
+	// <b> is stored in the database as it cannot be put since the names are validated
+	_, dbFile := newDatabaseFile(t)
+	db := api.NewDatabaseImpl(dbFile)
+	for _, r := range []*api.Record{
+		{Name: "docs", To: "https://example.com/docs"},
+		{Name: "<b>", To: "https://example.com/b"},
+	} {
+		if err := db.Put(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := api.NewServerImpl(db)
+	server.SetFallbackStore(api.NewFallbackMemory(&api.Fallback{Mode: api.FallbackSuggest}))
+	handler := api.RedirectHandler(server, "/c/")
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/c/doc", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("want %d, got %d", http.StatusNotFound, w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<a href="/c/docs">docs</a>`) {
+		t.Errorf("no link to the suggestion: %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/c/%3Cb", nil))
+	if body := w.Body.String(); strings.Contains(body, "<b>") {
+		t.Errorf("names are not escaped: %s", body)
+	}
+}
+
+func TestServerImplSuggestWithoutScan(t *testing.T) {
+	_, dbFile := newDatabaseFile(t)
+	db := &scanCountingDatabase{Database: api.NewDatabaseImpl(dbFile)}
+	server := api.NewServerImpl(db)
+	server.SetFallbackStore(api.NewFallbackMemory(&api.Fallback{Mode: api.FallbackSuggest}))
+	ctx := context.Background()
+	for _, r := range []*api.Record{
+		{Name: "docs", To: "https://example.com/docs"},
+		{Name: "gh/{org}", To: "https://github.com/{org}"},
+	} {
+		if _, err := server.Put(ctx, &api.PutRequest{Record: r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db.scans.Store(0)
+	for i := 0; i < 2; i++ {
+		res, err := server.Redirect(ctx, &api.RedirectRequest{Name: "doc"})
+		if !errors.Is(err, api.ErrRecordNotFound) {
+			t.Fatalf("want ErrRecordNotFound, got %v", err)
+		}
+		if want := []string{"docs"}; !reflect.DeepEqual(want, res.Suggestions) {
+			t.Errorf("want %v, got %v", want, res.Suggestions)
+		}
+	}
+	if n := db.scans.Load(); n != 0 {
+		t.Errorf("want no scans, got %d", n)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"html/template"
 	"io"
 	"log/slog"
 	"net/http"
@@ -13,9 +15,15 @@ import (
 
 var (
 	ErrNotFound      = errors.New("NotFound")
+	ErrBadRequest    = errors.New("BadRequest")
 	ErrInternalError = errors.New("InternalError")
 )
 
+// errorResponse is the common part of the responses.
+type errorResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
 // Post posts request built from server request struct.
 func Post[ReqT any, ResT any](client *http.Client, url string) func(context.Context, ReqT) (ResT, error) {
 	return func(ctx context.Context, r ReqT) (ResT, error) {
@@ -43,6 +51,22 @@ func Post[ReqT any, ResT any](client *http.Client, url string) func(context.Cont
 			return res, nil
 		case http.StatusNotFound:
 			return res, ErrNotFound
+		case http.StatusUnauthorized:
+			return res, ErrUnauthorized
+		case http.StatusForbidden:
+			return res, ErrForbidden
+		case http.StatusBadRequest:
+			var e errorResponse
+			if err := json.Unmarshal(body, &e); err != nil || e.Error == "" {
+				return res, ErrBadRequest
+			}
+			return res, fmt.Errorf("%w, %s", ErrBadRequest, e.Error)
+		case http.StatusConflict:
+			var e errorResponse
+			if err := json.Unmarshal(body, &e); err != nil || e.Error == "" {
+				return res, ErrConflict
+			}
+			return res, fmt.Errorf("%w, %s", ErrConflict, strings.TrimPrefix(e.Error, ErrConflict.Error()+", "))
 		default:
 			return res, ErrInternalError
 		}
@@ -76,11 +100,26 @@ func API[ReqT any, ResT any](f func(context.Context, ReqT) (ResT, error)) http.H
 			return
 		}
 
-		res, err := f(r.Context(), req)
+		res, err := f(withRemoteAddr(r.Context(), r.RemoteAddr), req)
 		switch {
-		case errors.Is(err, ErrRecordNotFound):
+		case errors.Is(err, ErrRecordNotFound), errors.Is(err, ErrDomainNotFound), errors.Is(err, ErrRevisionNotFound):
 			w.WriteHeader(http.StatusNotFound)
 			logger.Info("handle", slog.String("error", "not found"))
+		case errors.Is(err, ErrForbidden):
+			w.WriteHeader(http.StatusForbidden)
+			logger.Info("handle", slog.Any("error", err))
+		case errors.Is(err, ErrInvalidRecord), errors.Is(err, ErrInvalidFallback), errors.Is(err, ErrInvalidDomain), errors.Is(err, ErrInvalidScan):
+			w.WriteHeader(http.StatusBadRequest)
+			if rb, err := json.Marshal(res); err == nil {
+				w.Write(rb)
+			}
+			logger.Info("handle", slog.Any("error", err))
+		case errors.Is(err, ErrConflict):
+			w.WriteHeader(http.StatusConflict)
+			if rb, err := json.Marshal(res); err == nil {
+				w.Write(rb)
+			}
+			logger.Info("handle", slog.Any("error", err))
 		case err != nil:
 			w.WriteHeader(http.StatusInternalServerError)
 			logger.Error("handle", slog.Any("error", err))
@@ -109,23 +148,61 @@ func RedirectHandler(redirector Redirector, pattern string) http.HandlerFunc {
 		name := strings.TrimPrefix(r.URL.Path, pattern)
 		logger := slog.With(slog.String("url", r.URL.String()), slog.String("name", name))
 		res, err := redirector.Redirect(r.Context(), &RedirectRequest{
-			Name: name,
+			Name:      name,
+			Host:      r.Host,
+			Query:     r.URL.RawQuery,
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
 		})
 		switch {
+		case errors.Is(err, ErrRecordNotFound) && res != nil && len(res.Suggestions) > 0:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusNotFound)
+			if err := suggestionsTemplate.Execute(w, suggestionsPage{
+				Name:        name,
+				Pattern:     pattern,
+				Suggestions: res.Suggestions,
+			}); err != nil {
+				logger.Error("write suggestions", slog.Any("error", err))
+			}
+			logger.Info("handle", slog.String("error", "not found"), slog.Any("suggestions", res.Suggestions))
 		case errors.Is(err, ErrRecordNotFound):
 			w.WriteHeader(http.StatusNotFound)
 			logger.Info("hanle", slog.String("error", "not found"))
+		case errors.Is(err, ErrRecordGone):
+			w.WriteHeader(http.StatusGone)
+			logger.Info("handle", slog.String("error", "gone"))
 		case err != nil:
 			w.WriteHeader(http.StatusInternalServerError)
 			logger.Error("handle", slog.Any("error", err))
 		default:
 			w.Header().Set("Location", res.To)
-			w.WriteHeader(http.StatusMovedPermanently)
-			logger.Info("handle", slog.String("to", res.To))
+			w.WriteHeader(res.StatusCode)
+			logger.Info("handle", slog.String("to", res.To), slog.Int("status", res.StatusCode))
 		}
 	}
 }
 
+type suggestionsPage struct {
+	Name        string
+	Pattern     string
+	Suggestions []string
+}
+
+var suggestionsTemplate = template.Must(template.New("suggestions").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Not Found</title></head>
+<body>
+<p>{{.Name}} is not found. Did you mean:</p>
+<ul>
+{{- range .Suggestions}}
+<li><a href="{{$.Pattern}}{{.}}">{{.}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
 func StatusHandler(w http.ResponseWriter, _ *http.Request) {
 	io.WriteString(w, "OK")
 }
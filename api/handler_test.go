@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestRedirectHandlerQuery(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "drop", To: "https://example.com/drop?a=1"},
+		&api.Record{Name: "pass", To: "https://example.com/pass?a=1#frag", Query: api.QueryPass},
+		&api.Record{Name: "pass-bare", To: "https://example.com/pass", Query: api.QueryPass},
+		&api.Record{Name: "request", To: "https://example.com/m?a=1&b=2#frag", Query: api.QueryMergeRequest},
+		&api.Record{Name: "target", To: "https://example.com/m?a=1&b=2", Query: api.QueryMergeTarget},
+		&api.Record{Name: "docs", To: "https://example.com/docs?lang=en", Match: api.MatchPrefix, Query: api.QueryMergeRequest},
+		&api.Record{Name: "raw", To: "https://example.com/m?z=1&q=a%20b&y=2&z=3", Query: api.QueryMergeRequest},
+	)
+	handler := api.RedirectHandler(server, "/c/")
+
+	for _, tc := range []struct {
+		title  string
+		target string
+		want   string
+	}{
+		{
+			title:  "drop",
+			target: "/c/drop?utm_source=x",
+			want:   "https://example.com/drop?a=1",
+		},
+		{
+			title:  "pass",
+			target: "/c/pass?utm_source=x&q=a+b",
+			want:   "https://example.com/pass?a=1&utm_source=x&q=a+b#frag",
+		},
+		{
+			title:  "pass keeps encoding as is",
+			target: "/c/pass-bare?q=%E3%81%82%20b&r=%2F",
+			want:   "https://example.com/pass?q=%E3%81%82%20b&r=%2F",
+		},
+		{
+			title:  "pass without query",
+			target: "/c/pass",
+			want:   "https://example.com/pass?a=1#frag",
+		},
+		{
+			title:  "merge preferring request",
+			target: "/c/request?b=3&c=4",
+			want:   "https://example.com/m?a=1&b=3&c=4#frag",
+		},
+		{
+			title:  "merge preferring target",
+			target: "/c/target?b=3&c=4",
+			want:   "https://example.com/m?a=1&b=2&c=4",
+		},
+		{
+			title:  "merge encodes values",
+			target: "/c/target?q=a+b&r=%26%3D",
+			want:   "https://example.com/m?a=1&b=2&q=a+b&r=%26%3D",
+		},
+		{
+			title:  "merge skips invalid pairs",
+			target: "/c/target?q=%zz&c=4",
+			want:   "https://example.com/m?a=1&b=2&c=4",
+		},
+		{
+			title:  "merge keeps the target as is",
+			target: "/c/raw?c=4&b=3",
+			want:   "https://example.com/m?z=1&q=a%20b&y=2&z=3&c=4&b=3",
+		},
+		{
+			title:  "merge replaces the keys in place",
+			target: "/c/raw?z=9",
+			want:   "https://example.com/m?z=9&q=a%20b&y=2",
+		},
+		{
+			title:  "prefix",
+			target: "/c/docs/guide?lang=ja",
+			want:   "https://example.com/docs/guide?lang=ja",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			if w.Code != http.StatusMovedPermanently {
+				t.Fatalf("want status %d, got %d", http.StatusMovedPermanently, w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -1,24 +1,95 @@
 package api
 
 import (
+	"crypto/tls"
 	"net/http"
 	"strings"
 )
 
-func ListenAndServe(addr string, server Server, redirector Redirector) error {
-	http.HandleFunc("/", mainHandler(server, redirector))
-	return http.ListenAndServe(addr, nil)
+// ListenAndServe serves the API and the redirects, requiring the tokens and the client certificates if given.
+func ListenAndServe(addr string, server Server, redirector Redirector, metrics *Metrics, tokens *Tokens, tlsConfig *tls.Config) error {
+	var requireClientCert bool
+	if tlsConfig != nil {
+		requireClientCert = tlsConfig.ClientCAs != nil
+	}
+	s := &http.Server{
+		Addr:      addr,
+		Handler:   mainHandler(server, redirector, metrics, tokens, requireClientCert),
+		TLSConfig: tlsConfig,
+	}
+	if tlsConfig != nil {
+		// the certificate is given by tlsConfig
+		return s.ListenAndServeTLS("", "")
+	}
+	return s.ListenAndServe()
+}
+
+var (
+	// publicRoutes do not require tokens.
+	publicRoutes = map[string]bool{
+		"/status": true,
+	}
+	// readOnlyRoutes require ScopeReadOnly, the other management routes require ScopeReadWrite.
+	readOnlyRoutes = map[string]bool{
+		"/scan":         true,
+		"/get":          true,
+		"/stats":        true,
+		"/history":      true,
+		"/fallback/get": true,
+		"/domain/scan":  true,
+		"/domain/get":   true,
+		"/metrics":      true,
+	}
+	// auditedRoutes are the routes changing the records whose calls rejected by the tokens are audited.
+	auditedRoutes = map[string]AuditOp{
+		"/put":      AuditOpPut,
+		"/delete":   AuditOpDelete,
+		"/batch":    AuditOpBatch,
+		"/rollback": AuditOpRollback,
+	}
+)
+
+func routeScope(route string) Scope {
+	if readOnlyRoutes[route] {
+		return ScopeReadOnly
+	}
+	return ScopeReadWrite
 }
 
-func mainHandler(server Server, redirector Redirector) http.HandlerFunc {
+func mainHandler(server Server, redirector Redirector, metrics *Metrics, tokens *Tokens, requireClientCert bool) http.HandlerFunc {
 	routes := map[string]http.HandlerFunc{
 		"/status": StatusHandler,
 		"/scan":   API(server.Scan),
 		"/get":    API(server.Get),
 		"/put":    API(server.Put),
 		"/delete": API(server.Delete),
+		"/batch":  API(server.Batch),
+		"/stats":  API(server.Stats),
+
+		"/history":  API(server.History),
+		"/rollback": API(server.Rollback),
+		"/audit":    API(server.Audit),
+
+		"/fallback/get": API(server.GetFallback),
+		"/fallback/put": API(server.PutFallback),
+
+		"/domain/scan":   API(server.ScanDomains),
+		"/domain/get":    API(server.GetDomain),
+		"/domain/put":    API(server.PutDomain),
+		"/domain/delete": API(server.DeleteDomain),
+
+		"/metrics": metrics.Handler().ServeHTTP,
+	}
+	for route, h := range routes {
+		if tokens != nil && !publicRoutes[route] {
+			h = tokens.Require(routeScope(route), h)
+		}
+		if requireClientCert && !publicRoutes[route] {
+			h = RequireClientCert(h)
+		}
+		routes[route] = metrics.Instrument(route, h)
 	}
-	redirect := RedirectHandler(redirector, "/c/")
+	redirect := metrics.InstrumentRedirect("/c/", RedirectHandler(redirector, "/c/"))
 	return func(w http.ResponseWriter, r *http.Request) {
 		if h, ok := routes[r.URL.Path]; ok {
 			h(w, r)
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// RecordIndex indexes the records for the redirects, never modified once built but the cached suggestions.
+type RecordIndex struct {
+	// namespaces are the namespaces having records
+	namespaces map[string]bool
+	// patterns are the pattern records by namespace in the order of the records
+	patterns map[string][]indexedPattern
+	// prefixes are the prefix records by namespace and name
+	prefixes map[string]map[string]*Record
+	// names are the names of the records but the patterns by namespace
+	names map[string][]string
+
+	suggestions   map[suggestionKey][]string
+	suggestionMux sync.Mutex
+}
+
+type suggestionKey struct {
+	namespace string
+	name      string
+	n         int
+}
+
+// maxCachedSuggestions is the number of the suggestions cached by RecordIndex.
+const maxCachedSuggestions = 1024
+
+type indexedPattern struct {
+	record  *Record
+	pattern *Pattern
+}
+
+func newRecordIndex(records []*Record) *RecordIndex {
+	var (
+		namespaces = map[string]bool{}
+		patterns   = map[string][]indexedPattern{}
+		prefixes   = map[string]map[string]*Record{}
+		names      = map[string][]string{}
+	)
+	for _, r := range records {
+		namespaces[r.Namespace] = true
+		if r.MatchMode() == MatchPrefix {
+			if prefixes[r.Namespace] == nil {
+				prefixes[r.Namespace] = map[string]*Record{}
+			}
+			prefixes[r.Namespace][r.Name] = r
+		}
+		if !IsPattern(r.Name) {
+			names[r.Namespace] = append(names[r.Namespace], r.Name)
+			continue
+		}
+		p, err := ParsePattern(r.Name)
+		if err != nil {
+			continue
+		}
+		patterns[r.Namespace] = append(patterns[r.Namespace], indexedPattern{
+			record:  r,
+			pattern: p,
+		})
+	}
+	return &RecordIndex{
+		namespaces: namespaces,
+		patterns:   patterns,
+		prefixes:   prefixes,
+		names:      names,
+	}
+}
+
+// HasNamespace reports whether there are records in the namespace.
+func (x *RecordIndex) HasNamespace(namespace string) bool {
+	return x.namespaces[namespace]
+}
+
+// MatchPattern returns the pattern record in the namespace matching the name and the captured parameters.
+func (x *RecordIndex) MatchPattern(namespace, name string) (*Record, map[string]string, bool) {
+	for _, p := range x.patterns[namespace] {
+		if params, ok := p.pattern.Match(name); ok {
+			return p.record, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// MatchPrefix returns the prefix record in the namespace with the longest name above the name and the rest.
+func (x *RecordIndex) MatchPrefix(namespace, name string) (*Record, string, bool) {
+	prefixes := x.prefixes[namespace]
+	for prefix := name; len(prefixes) > 0; {
+		i := strings.LastIndex(prefix, "/")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+		if r, ok := prefixes[prefix]; ok {
+			return r, name[i+1:], true
+		}
+	}
+	return nil, "", false
+}
+
+// Suggest returns the names in the namespace closest to the name, at most n.
+func (x *RecordIndex) Suggest(namespace, name string, n int) []string {
+	key := suggestionKey{
+		namespace: namespace,
+		name:      name,
+		n:         n,
+	}
+	x.suggestionMux.Lock()
+	defer x.suggestionMux.Unlock()
+	names, ok := x.suggestions[key]
+	if !ok {
+		names = suggest(name, x.names[namespace], n)
+		if len(x.suggestions) >= maxCachedSuggestions || x.suggestions == nil {
+			x.suggestions = map[suggestionKey][]string{}
+		}
+		x.suggestions[key] = names
+	}
+	return append([]string(nil), names...)
+}
+
+// lazyRecordIndex builds the RecordIndex by a scan on the first use after the writes invalidating it.
+type lazyRecordIndex struct {
+	index *RecordIndex
+	mux   sync.Mutex
+}
+
+func (x *lazyRecordIndex) get(ctx context.Context, scan func(ctx context.Context) ([]*Record, error)) (*RecordIndex, error) {
+	x.mux.Lock()
+	defer x.mux.Unlock()
+	if x.index != nil {
+		return x.index, nil
+	}
+	records, err := scan(ctx)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return nil, err
+	}
+	x.index = newRecordIndex(records)
+	return x.index, nil
+}
+
+// invalidate makes the next get rebuild the index, called after the writes.
+func (x *lazyRecordIndex) invalidate() {
+	x.mux.Lock()
+	defer x.mux.Unlock()
+	x.index = nil
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCompactInterval is how often the journal is compacted regardless of its size.
+const DefaultCompactInterval = 10 * time.Minute
+
+type JournalOp string
+
+const (
+	JournalOpPut    JournalOp = "put"
+	JournalOpDelete JournalOp = "delete"
+	// JournalOpBatch applies the entries of the batch, appended as a single entry to be atomic.
+	JournalOpBatch JournalOp = "batch"
+)
+
+// JournalEntry is a write operation appended to the journal.
+type JournalEntry struct {
+	Op     JournalOp `json:"op"`
+	Record *Record   `json:"record,omitempty"`
+	// Namespace and Name identify the deleted record.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Entries are the entries of the batch.
+	Entries []*JournalEntry `json:"entries,omitempty"`
+}
+
+// Journal is an append-only log of write operations.
+type Journal interface {
+	// Append writes the entry durably and returns the number of entries in the journal.
+	Append(entry *JournalEntry) (int, error)
+	Read() ([]*JournalEntry, error)
+	// Truncate discards all entries.
+	Truncate() error
+}
+
+// ReplayJournal applies entries to records in order.
+func ReplayJournal(records []*Record, entries []*JournalEntry) []*Record {
+	for _, e := range entries {
+		switch e.Op {
+		case JournalOpPut:
+			if e.Record == nil {
+				continue
+			}
+			var found bool
+			for i, r := range records {
+				if r.key() == e.Record.key() {
+					records[i] = e.Record
+					found = true
+					break
+				}
+			}
+			if !found {
+				records = append(records, e.Record)
+			}
+		case JournalOpDelete:
+			var rs []*Record
+			for _, r := range records {
+				if r.key() != recordKey(e.Namespace, e.Name) {
+					rs = append(rs, r)
+				}
+			}
+			records = rs
+		case JournalOpBatch:
+			records = ReplayJournal(records, e.Entries)
+		}
+	}
+	return records
+}
+
+type journalFile struct {
+	filename string
+	size     int
+	mux      sync.Mutex
+}
+
+// NewJournalFile opens the journal file, creating it if necessary.
+func NewJournalFile(filename string) (Journal, error) {
+	j := &journalFile{
+		filename: filename,
+	}
+	if err := j.dropPartialEntry(); err != nil {
+		return nil, err
+	}
+	entries, err := j.Read()
+	if err != nil {
+		return nil, err
+	}
+	j.size = len(entries)
+	return j, nil
+}
+
+// dropPartialEntry removes the partial last line left by a crash during Append.
+func (j *journalFile) dropPartialEntry() error {
+	f, err := os.OpenFile(j.filename, os.O_RDWR, 0)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("%w, open journal %v", ErrReadDatabase, err)
+	}
+	defer f.Close()
+	if err := dropPartialLine(f); err != nil {
+		return fmt.Errorf("%w, repair journal %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
+
+func (j *journalFile) Append(entry *JournalEntry) (int, error) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return 0, fmt.Errorf("%w, marshal journal", ErrWriteDatabase)
+	}
+	f, err := os.OpenFile(j.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("%w, open journal %v", ErrWriteDatabase, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return 0, fmt.Errorf("%w, write journal %v", ErrWriteDatabase, err)
+	}
+	if err := f.Sync(); err != nil {
+		return 0, fmt.Errorf("%w, sync journal %v", ErrWriteDatabase, err)
+	}
+	j.size++
+	return j.size, nil
+}
+
+func (j *journalFile) Read() ([]*JournalEntry, error) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	b, err := os.ReadFile(j.filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("%w, read journal %v", ErrReadDatabase, err)
+	}
+
+	lines := bytes.Split(b, []byte{'\n'})
+	// the last element is either empty or a partial line left by a crash during Append
+	lines = lines[:len(lines)-1]
+
+	var entries []*JournalEntry
+	for _, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var e JournalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%w, unmarshal journal", ErrReadDatabase)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+func (j *journalFile) Stat() (os.FileInfo, error) {
+	return os.Stat(j.filename)
+}
+
+func (j *journalFile) Truncate() error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	if err := writeFileAtomic(j.filename, nil); err != nil {
+		return fmt.Errorf("%w, truncate journal %v", ErrWriteDatabase, err)
+	}
+	j.size = 0
+	return nil
+}
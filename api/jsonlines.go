@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+)
+
+// readJSONLines returns the lines of the JSON Lines file, nil if the file does not exist.
+func readJSONLines(filename string) ([][]byte, error) {
+	b, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	lines := bytes.Split(b, []byte{'\n'})
+	// the last element is either empty or a partial line removed by the next appendJSONLines
+	lines = lines[:len(lines)-1]
+
+	var rs [][]byte
+	for _, line := range lines {
+		if len(line) > 0 {
+			rs = append(rs, line)
+		}
+	}
+	return rs, nil
+}
+
+// appendJSONLines appends the values to the JSON Lines file in a single synced write.
+func appendJSONLines[T any](filename string, values []T) error {
+	var buf bytes.Buffer
+	for _, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := dropPartialLine(file); err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// dropPartialLine truncates the partial last line left by a crash during an append.
+func dropPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	b, err := io.ReadAll(io.NewSectionReader(file, 0, size))
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(int64(bytes.LastIndexByte(b, '\n') + 1)); err != nil {
+		return err
+	}
+	return file.Sync()
+}
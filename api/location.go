@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+)
+
+// location builds the Location header from the redirect-to, the rest of the path and the request query.
+func location(to string, policy QueryPolicy, rest, rawQuery string) string {
+	if policy == QueryDrop {
+		rawQuery = ""
+	}
+	if rest == "" && rawQuery == "" {
+		return to
+	}
+
+	escapedRest := escapePath(rest)
+	u, err := url.Parse(to)
+	if err != nil {
+		// not a URL, but keep going as far as possible
+		if escapedRest != "" {
+			to = strings.TrimSuffix(to, "/") + "/" + escapedRest
+		}
+		if rawQuery != "" && strings.Contains(to, "?") {
+			to += "&" + rawQuery
+		} else if rawQuery != "" {
+			to += "?" + rawQuery
+		}
+		return to
+	}
+
+	if escapedRest != "" {
+		u = u.JoinPath(escapedRest)
+	}
+	if rawQuery != "" {
+		u.RawQuery = mergeQuery(policy, u.RawQuery, rawQuery)
+	}
+	return u.String()
+}
+
+func escapePath(p string) string {
+	if p == "" {
+		return ""
+	}
+	segments := strings.Split(p, "/")
+	for i, x := range segments {
+		segments[i] = url.PathEscape(x)
+	}
+	return strings.Join(segments, "/")
+}
+
+// mergeQuery merges the raw query string of the request into the one of the redirect-to.
+func mergeQuery(policy QueryPolicy, target, request string) string {
+	switch policy {
+	case QueryPass:
+		if target == "" {
+			return request
+		}
+		return target + "&" + request
+	case QueryMergeRequest:
+		return mergeValues(target, request, true)
+	case QueryMergeTarget:
+		return mergeValues(target, request, false)
+	default:
+		return target
+	}
+}
+
+// mergeValues merges the request query into the raw one of the redirect-to, replacing its keys if override.
+func mergeValues(target, request string, override bool) string {
+	values, keys := parseQuery(request)
+	var (
+		pairs    []string
+		inTarget = map[string]bool{}
+	)
+	for _, raw := range strings.Split(target, "&") {
+		if raw == "" {
+			continue
+		}
+		k, _, _ := strings.Cut(raw, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			key = k
+		}
+		switch {
+		case !override || values[key] == nil:
+			pairs = append(pairs, raw)
+		case !inTarget[key]:
+			// replace the first pair of the key and drop the others
+			pairs = append(pairs, encodePairs(key, values[key])...)
+		}
+		inTarget[key] = true
+	}
+	for _, key := range keys {
+		if !inTarget[key] {
+			pairs = append(pairs, encodePairs(key, values[key])...)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func encodePairs(key string, values []string) []string {
+	pairs := make([]string, len(values))
+	for i, v := range values {
+		pairs[i] = url.QueryEscape(key) + "=" + url.QueryEscape(v)
+	}
+	return pairs
+}
+
+// parseQuery parses the query string and returns the values and the keys in their order, skipping invalid pairs.
+func parseQuery(query string) (url.Values, []string) {
+	var (
+		values = url.Values{}
+		keys   []string
+	)
+	for _, raw := range strings.Split(query, "&") {
+		if raw == "" || strings.Contains(raw, ";") {
+			continue
+		}
+		k, v, _ := strings.Cut(raw, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			continue
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			continue
+		}
+		if values[key] == nil {
+			keys = append(keys, key)
+		}
+		values.Add(key, value)
+	}
+	return values, keys
+}
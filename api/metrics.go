@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "redirect_store"
+
+// Metrics are the Prometheus metrics of the API server.
+type Metrics struct {
+	registry         *prometheus.Registry
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	redirects        *prometheus.CounterVec
+	databaseDuration *prometheus.HistogramVec
+	databaseErrors   *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Number of the requests by route and status code.",
+		}, []string{"route", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route"}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "redirects_total",
+			Help:      "Number of the redirects by result, hit, miss, gone or error.",
+		}, []string{"result"}),
+		databaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "database_duration_seconds",
+			Help:      "Latency of the database operations by kind, read or write.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+		databaseErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "database_errors_total",
+			Help:      "Number of the failed database operations by error.",
+		}, []string{"error"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.redirects,
+		m.databaseDuration,
+		m.databaseErrors,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// RegisterHitRecorder counts the hits the recorder dropped.
+func (m *Metrics) RegisterHitRecorder(r *HitRecorder) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "hits_dropped_total",
+		Help:      "Number of the hits dropped because the buffer was full.",
+	}, func() float64 {
+		return float64(r.Dropped())
+	}))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// statusRecorder remembers the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Instrument counts and times the requests to the handler of the route.
+func (m *Metrics) Instrument(route string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			rec   = &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+			start = time.Now()
+		)
+		h(rec, r)
+		m.requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(route, strconv.Itoa(rec.code)).Inc()
+	}
+}
+
+// InstrumentRedirect instruments the handler of the redirects, counting them by the result as well.
+func (m *Metrics) InstrumentRedirect(route string, h http.HandlerFunc) http.HandlerFunc {
+	return m.Instrument(route, func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		h(rec, r)
+		m.redirects.WithLabelValues(redirectResult(rec.code)).Inc()
+	})
+}
+
+func redirectResult(code int) string {
+	switch {
+	case code == http.StatusNotFound:
+		return "miss"
+	case code == http.StatusGone:
+		return "gone"
+	case 300 <= code && code < 400:
+		return "hit"
+	default:
+		return "error"
+	}
+}
+
+var (
+	_ Database = &instrumentedDatabase{}
+)
+
+// InstrumentDatabase times the operations of the database and counts their errors.
+func InstrumentDatabase(db Database, m *Metrics) Database {
+	return &instrumentedDatabase{
+		db:      db,
+		metrics: m,
+	}
+}
+
+type instrumentedDatabase struct {
+	db      Database
+	metrics *Metrics
+}
+
+const (
+	databaseOpRead  = "read"
+	databaseOpWrite = "write"
+)
+
+func (db *instrumentedDatabase) observe(op string, start time.Time, err error) {
+	db.metrics.databaseDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	for _, e := range []error{ErrConnectDatabase, ErrReadDatabase, ErrWriteDatabase} {
+		if errors.Is(err, e) {
+			db.metrics.databaseErrors.WithLabelValues(e.Error()).Inc()
+		}
+	}
+}
+
+func (db *instrumentedDatabase) Scan(ctx context.Context) ([]*Record, error) {
+	start := time.Now()
+	records, err := db.db.Scan(ctx)
+	db.observe(databaseOpRead, start, err)
+	return records, err
+}
+
+func (db *instrumentedDatabase) Index(ctx context.Context) (*RecordIndex, error) {
+	start := time.Now()
+	index, err := db.db.Index(ctx)
+	db.observe(databaseOpRead, start, err)
+	return index, err
+}
+
+func (db *instrumentedDatabase) Get(ctx context.Context, namespace, name string) (*Record, error) {
+	start := time.Now()
+	record, err := db.db.Get(ctx, namespace, name)
+	db.observe(databaseOpRead, start, err)
+	return record, err
+}
+
+func (db *instrumentedDatabase) Put(ctx context.Context, record *Record) error {
+	start := time.Now()
+	err := db.db.Put(ctx, record)
+	db.observe(databaseOpWrite, start, err)
+	return err
+}
+
+func (db *instrumentedDatabase) Batch(ctx context.Context, ops []*BatchOperation) error {
+	start := time.Now()
+	err := db.db.Batch(ctx, ops)
+	db.observe(databaseOpWrite, start, err)
+	return err
+}
+
+func (db *instrumentedDatabase) Delete(ctx context.Context, namespace, name string) error {
+	start := time.Now()
+	err := db.db.Delete(ctx, namespace, name)
+	db.observe(databaseOpWrite, start, err)
+	return err
+}
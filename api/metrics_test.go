@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestMetrics(t *testing.T) {
+	var (
+		metrics  = api.NewMetrics()
+		_, f     = newDatabaseFile(t)
+		server   = api.NewServerImpl(api.InstrumentDatabase(api.NewDatabaseImpl(f), metrics))
+		get      = metrics.Instrument("/get", api.API(server.Get))
+		redirect = metrics.InstrumentRedirect("/c/", api.RedirectHandler(server, "/c/"))
+	)
+	if _, err := server.Put(context.Background(), &api.PutRequest{
+		Record: &api.Record{Name: "docs", To: "https://example.com/docs"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{"/c/docs", "/c/docs", "/c/unknown"} {
+		redirect(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	get(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/get", strings.NewReader(`{"name":"docs"}`)))
+	get(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/get", nil))
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`redirect_store_requests_total{code="301",route="/c/"} 2`,
+		`redirect_store_requests_total{code="404",route="/c/"} 1`,
+		`redirect_store_requests_total{code="200",route="/get"} 1`,
+		`redirect_store_requests_total{code="405",route="/get"} 1`,
+		`redirect_store_request_duration_seconds_count{route="/c/"} 3`,
+		`redirect_store_redirects_total{result="hit"} 2`,
+		`redirect_store_redirects_total{result="miss"} 1`,
+		`redirect_store_database_duration_seconds_count{op="write"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("no %s in\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsHitsDropped(t *testing.T) {
+	var (
+		metrics  = api.NewMetrics()
+		recorder = api.NewHitRecorder(api.NewHitMemory(), 1, time.Hour)
+	)
+	metrics.RegisterHitRecorder(recorder)
+	for i := 0; i < 3; i++ {
+		recorder.Record(api.NewHit(api.DefaultNamespace, "a", time.Now(), "", ""))
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := "redirect_store_hits_dropped_total 2"; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("no %s in\n%s", want, w.Body.String())
+	}
+}
+
+func TestMetricsDatabaseErrors(t *testing.T) {
+	var (
+		metrics = api.NewMetrics()
+		db      = api.InstrumentDatabase(api.NewDatabaseImpl(api.NewDatabaseFile(t.TempDir()+"/missing/api.db")), metrics)
+	)
+	if _, err := db.Scan(context.Background()); err == nil {
+		t.Fatal("want error")
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `redirect_store_database_errors_total{error="ReadDatabase"} 1`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("no %s in\n%s", want, w.Body.String())
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// Migration is a versioned change of the SQL schema.
+type Migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// sqliteMigrations is the history of the schema of SQLDatabase; never edit applied ones.
+var sqliteMigrations = []Migration{
+	{
+		Version:     1,
+		Description: "create records",
+		Statements: []string{
+			`CREATE TABLE records (
+  name TEXT PRIMARY KEY,
+  to_url TEXT NOT NULL
+)`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "add records.status_code",
+		Statements: []string{
+			`ALTER TABLE records ADD COLUMN status_code INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "add records.match_mode",
+		Statements: []string{
+			`ALTER TABLE records ADD COLUMN match_mode TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "add records.query_policy",
+		Statements: []string{
+			`ALTER TABLE records ADD COLUMN query_policy TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "add records.not_before and records.expires_at",
+		Statements: []string{
+			`ALTER TABLE records ADD COLUMN not_before TEXT`,
+			`ALTER TABLE records ADD COLUMN expires_at TEXT`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "key records by namespace and name",
+		Statements: []string{
+			// SQLite cannot change the primary key of a table
+			`CREATE TABLE records_new (
+  namespace TEXT NOT NULL DEFAULT '',
+  name TEXT NOT NULL,
+  to_url TEXT NOT NULL,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  match_mode TEXT NOT NULL DEFAULT '',
+  query_policy TEXT NOT NULL DEFAULT '',
+  not_before TEXT,
+  expires_at TEXT,
+  PRIMARY KEY (namespace, name)
+)`,
+			// keep the rowids for the order of Scan
+			`INSERT INTO records_new (rowid, name, to_url, status_code, match_mode, query_policy, not_before, expires_at)
+SELECT rowid, name, to_url, status_code, match_mode, query_policy, not_before, expires_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "add records.version",
+		Statements: []string{
+			`ALTER TABLE records ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+}
+
+// Migrate applies the migrations newer than the current schema version, each in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB, migrations []Migration) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  description TEXT NOT NULL
+)`); err != nil {
+		return fmt.Errorf("%w, create schema_migrations %v", ErrConnectDatabase, err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("%w, read schema version %v", ErrConnectDatabase, err)
+	}
+
+	ms := append([]Migration(nil), migrations...)
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+	for _, m := range ms {
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("%w, migrate to %d %s %v", ErrConnectDatabase, m.Version, m.Description, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.Statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, description) VALUES (?, ?)`,
+		m.Version, m.Description,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultNamespace is the namespace of the records without a namespace.
+const DefaultNamespace = ""
+
+// ValidateNamespace checks that the namespace is a DNS label, lower case alphanumerics and hyphens.
+func ValidateNamespace(namespace string) error {
+	if namespace == DefaultNamespace {
+		return nil
+	}
+	if len(namespace) > 63 {
+		return fmt.Errorf("%w, namespace %s is longer than 63", ErrInvalidRecord, namespace)
+	}
+	for i, c := range namespace {
+		switch {
+		case 'a' <= c && c <= 'z', '0' <= c && c <= '9':
+		case c == '-' && i > 0 && i < len(namespace)-1:
+		default:
+			return fmt.Errorf("%w, namespace %s must consist of lower case alphanumerics and inner hyphens", ErrInvalidRecord, namespace)
+		}
+	}
+	return nil
+}
+
+// recordKey identifies a record across the namespaces, the name itself in the default namespace.
+func recordKey(namespace, name string) string {
+	if namespace == DefaultNamespace {
+		return name
+	}
+	return namespace + "\x00" + name
+}
+
+func (r *Record) key() string {
+	return recordKey(r.Namespace, r.Name)
+}
+
+// splitNamespace splits the path like <namespace>/<name>.
+func splitNamespace(path string) (string, string, bool) {
+	namespace, name, ok := strings.Cut(path, "/")
+	if !ok || name == "" || namespace == DefaultNamespace || ValidateNamespace(namespace) != nil {
+		return "", "", false
+	}
+	return namespace, name, true
+}
+
+// qualifiedName returns the name in the namespace as a path like <namespace>/<name>.
+func qualifiedName(namespace, name string) string {
+	if namespace == DefaultNamespace {
+		return name
+	}
+	return namespace + "/" + name
+}
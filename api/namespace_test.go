@@ -0,0 +1,185 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestValidateNamespace(t *testing.T) {
+	for _, tc := range []struct {
+		namespace string
+		err       bool
+	}{
+		{namespace: api.DefaultNamespace},
+		{namespace: "team"},
+		{namespace: "team-a1"},
+		{namespace: "Team", err: true},
+		{namespace: "-team", err: true},
+		{namespace: "team-", err: true},
+		{namespace: "te/am", err: true},
+		{namespace: "te_am", err: true},
+	} {
+		t.Run(tc.namespace, func(t *testing.T) {
+			err := api.ValidateNamespace(tc.namespace)
+			if tc.err != errors.Is(err, api.ErrInvalidRecord) {
+				t.Errorf("want error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestServerImplNamespaces(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "docs", To: "https://example.com/docs"},
+		&api.Record{Name: "other/docs", To: "https://example.com/other"},
+		&api.Record{Namespace: "team", Name: "docs", To: "https://team.example.com/docs"},
+		&api.Record{Namespace: "team", Name: "gh/{repo}", To: "https://github.com/team/{repo}"},
+		&api.Record{Namespace: "ops", Name: "gh/{repo}", To: "https://github.com/ops/{repo}"},
+	)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		title     string
+		namespace string
+		name      string
+		want      string
+	}{
+		{title: "default namespace", name: "docs", want: "https://example.com/docs"},
+		{title: "namespace in path", name: "team/docs", want: "https://team.example.com/docs"},
+		{title: "namespace in request", namespace: "team", name: "docs", want: "https://team.example.com/docs"},
+		{title: "pattern in namespace", name: "ops/gh/x", want: "https://github.com/ops/x"},
+		{title: "segment without records is a name", name: "other/docs", want: "https://example.com/other"},
+		{title: "not in namespace", name: "team/other"},
+		{title: "pattern is not shared", namespace: "", name: "gh/x"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			got, err := server.Redirect(ctx, &api.RedirectRequest{
+				Namespace: tc.namespace,
+				Name:      tc.name,
+			})
+			if tc.want == "" {
+				if !errors.Is(err, api.ErrRecordNotFound) {
+					t.Fatalf("want ErrRecordNotFound, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.To != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got.To)
+			}
+		})
+	}
+
+	t.Run("scan namespace", func(t *testing.T) {
+		res, err := server.Scan(ctx, &api.ScanRequest{Namespace: "team"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Records) != 2 {
+			t.Errorf("want 2 records, got %d", len(res.Records))
+		}
+		res, err = server.Scan(ctx, &api.ScanRequest{AllNamespaces: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Records) != 5 {
+			t.Errorf("want 5 records, got %d", len(res.Records))
+		}
+	})
+
+	t.Run("invalid namespace", func(t *testing.T) {
+		if _, err := server.Put(ctx, &api.PutRequest{
+			Record: &api.Record{Namespace: "Team", Name: "x", To: "https://example.com/"},
+		}); !errors.Is(err, api.ErrInvalidRecord) {
+			t.Errorf("want ErrInvalidRecord, got %v", err)
+		}
+	})
+}
+
+func TestServerImplNamespaceAndPrefix(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "docs", To: "https://example.com/docs/", Match: api.MatchPrefix},
+		&api.Record{Namespace: "docs", Name: "x", To: "https://docs.example.com/x"},
+	)
+	for _, tc := range []struct {
+		name string
+		want string
+	}{
+		{name: "docs", want: "https://example.com/docs/"},
+		{name: "docs/x", want: "https://docs.example.com/x"},
+		{name: "docs/guide", want: "https://example.com/docs/guide"},
+		{name: "docs/guide/install", want: "https://example.com/docs/guide/install"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := server.Redirect(context.Background(), &api.RedirectRequest{Name: tc.name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.To != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got.To)
+			}
+		})
+	}
+}
+
+func TestNamespaceTokens(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "team", Scope: api.ScopeReadWrite, Hash: api.HashToken("team-token"), Namespaces: []string{"team"}},
+		{Name: "admin", Scope: api.ScopeReadWrite, Hash: api.HashToken("admin-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t,
+		&api.Record{Name: "docs", To: "https://example.com/docs"},
+		&api.Record{Namespace: "team", Name: "docs", To: "https://team.example.com/docs"},
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", tokens.Require(api.ScopeReadOnly, api.API(server.Scan)))
+	mux.HandleFunc("/get", tokens.Require(api.ScopeReadOnly, api.API(server.Get)))
+	mux.HandleFunc("/put", tokens.Require(api.ScopeReadWrite, api.API(server.Put)))
+	mux.HandleFunc("/fallback/put", tokens.Require(api.ScopeReadWrite, api.API(server.PutFallback)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	newClient := func(token string) api.Client {
+		return api.NewClientImpl(ts.URL, &http.Client{
+			Transport: api.NewTokenTransport(token, nil),
+		})
+	}
+	var (
+		ctx   = context.Background()
+		team  = newClient("team-token")
+		admin = newClient("admin-token")
+	)
+
+	if _, err := team.WithNamespace("team").Get(ctx, "docs"); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	if _, err := team.Get(ctx, "docs"); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("want ErrForbidden, got %v", err)
+	}
+	if _, err := team.WithNamespace("ops").Put(ctx, &api.Record{Name: "x", To: "https://example.com/"}); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("want ErrForbidden, got %v", err)
+	}
+	if _, err := team.PutFallback(ctx, &api.Fallback{Mode: api.FallbackNone}); !errors.Is(err, api.ErrForbidden) {
+		t.Errorf("want ErrForbidden, got %v", err)
+	}
+	if _, err := admin.WithNamespace("team").Get(ctx, "docs"); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+	got, err := team.WithNamespace("team").Put(ctx, &api.Record{Name: "new", To: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Namespace != "team" {
+		t.Errorf("want namespace team, got %s", got.Namespace)
+	}
+}
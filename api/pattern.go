@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidPattern = errors.New("InvalidPattern")
+)
+
+// Pattern is a record name with parameters matching whole path segments like gh/{org}/{repo}.
+type Pattern struct {
+	segments []patternSegment
+}
+
+type patternSegment struct {
+	literal string
+	// param is the parameter name, empty if the segment is a literal
+	param string
+}
+
+// IsPattern reports whether the name has parameters.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "{}")
+}
+
+// ParsePattern parses the record name as a pattern.
+func ParsePattern(name string) (*Pattern, error) {
+	var (
+		parts    = strings.Split(name, "/")
+		segments = make([]patternSegment, len(parts))
+		params   = map[string]bool{}
+	)
+	for i, part := range parts {
+		if !strings.ContainsAny(part, "{}") {
+			segments[i] = patternSegment{
+				literal: part,
+			}
+			continue
+		}
+		if !strings.HasPrefix(part, "{") || !strings.HasSuffix(part, "}") {
+			return nil, fmt.Errorf("%w, %s: a parameter must be a whole segment", ErrInvalidPattern, name)
+		}
+		param := part[1 : len(part)-1]
+		if !isParamName(param) {
+			return nil, fmt.Errorf("%w, %s: invalid parameter name %q", ErrInvalidPattern, name, param)
+		}
+		if params[param] {
+			return nil, fmt.Errorf("%w, %s: duplicate parameter %s", ErrInvalidPattern, name, param)
+		}
+		params[param] = true
+		segments[i] = patternSegment{
+			param: param,
+		}
+	}
+	return &Pattern{
+		segments: segments,
+	}, nil
+}
+
+func isParamName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && '0' <= c && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Params returns the parameter names in order.
+func (p *Pattern) Params() []string {
+	var params []string
+	for _, s := range p.segments {
+		if s.param != "" {
+			params = append(params, s.param)
+		}
+	}
+	return params
+}
+
+// Match returns the parameters captured from the name.
+func (p *Pattern) Match(name string) (map[string]string, bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, s := range p.segments {
+		switch {
+		case s.param == "":
+			if parts[i] != s.literal {
+				return nil, false
+			}
+		case parts[i] == "":
+			return nil, false
+		default:
+			params[s.param] = parts[i]
+		}
+	}
+	return params, true
+}
+
+// Overlaps reports whether some name matches both patterns.
+func (p *Pattern) Overlaps(other *Pattern) bool {
+	if len(p.segments) != len(other.segments) {
+		return false
+	}
+	for i, s := range p.segments {
+		o := other.segments[i]
+		if s.param == "" && o.param == "" && s.literal != o.literal {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateTemplate checks that the template is well-formed and refers only to the parameters.
+func ValidateTemplate(template string, params []string) error {
+	_, err := expandTemplate(template, func(param string, _ bool) (string, error) {
+		if !slices.Contains(params, param) {
+			return "", fmt.Errorf("%w, %s: unknown parameter %s", ErrInvalidPattern, template, param)
+		}
+		return "", nil
+	})
+	return err
+}
+
+// ExpandTemplate replaces {param} with the values escaped as a path segment or a query component.
+func ExpandTemplate(template string, params map[string]string) (string, error) {
+	return expandTemplate(template, func(param string, query bool) (string, error) {
+		v, ok := params[param]
+		if !ok {
+			return "", fmt.Errorf("%w, %s: unknown parameter %s", ErrInvalidPattern, template, param)
+		}
+		if query {
+			return url.QueryEscape(v), nil
+		}
+		return url.PathEscape(v), nil
+	})
+}
+
+// expandTemplate replaces {param} with the value, telling whether it is in the query or the fragment.
+func expandTemplate(template string, value func(param string, query bool) (string, error)) (string, error) {
+	var (
+		b     strings.Builder
+		rest  = template
+		query bool
+	)
+	for {
+		i := strings.IndexAny(rest, "{}")
+		if i < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		if rest[i] == '}' {
+			return "", fmt.Errorf("%w, %s: unmatched }", ErrInvalidPattern, template)
+		}
+		b.WriteString(rest[:i])
+		query = query || strings.ContainsAny(rest[:i], "?#")
+		rest = rest[i+1:]
+
+		j := strings.IndexAny(rest, "{}")
+		if j < 0 || rest[j] == '{' {
+			return "", fmt.Errorf("%w, %s: unmatched {", ErrInvalidPattern, template)
+		}
+		param := rest[:j]
+		if !isParamName(param) {
+			return "", fmt.Errorf("%w, %s: invalid parameter name %q", ErrInvalidPattern, template, param)
+		}
+		v, err := value(param, query)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+		rest = rest[j+1:]
+	}
+}
+
+// ValidatePattern checks the name as a pattern and the redirect-to as its template.
+func ValidatePattern(name, to string) error {
+	p, err := ParsePattern(name)
+	if err != nil {
+		return err
+	}
+	return ValidateTemplate(to, p.Params())
+}
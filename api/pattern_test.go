@@ -0,0 +1,131 @@
+package api_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestParsePattern(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		params []string
+		err    bool
+	}{
+		{name: "gh/{org}/{repo}", params: []string{"org", "repo"}},
+		{name: "gh/{org}/issues", params: []string{"org"}},
+		{name: "gh/x{org}", err: true},
+		{name: "gh/{org", err: true},
+		{name: "gh/{}", err: true},
+		{name: "gh/{1x}", err: true},
+		{name: "gh/{org}/{org}", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := api.ParsePattern(tc.name)
+			if tc.err {
+				if !errors.Is(err, api.ErrInvalidPattern) {
+					t.Fatalf("want ErrInvalidPattern, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Params(); !reflect.DeepEqual(tc.params, got) {
+				t.Errorf("want %v, got %v", tc.params, got)
+			}
+		})
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	p, err := api.ParsePattern("gh/{org}/{repo}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		want map[string]string
+	}{
+		{name: "gh/berquerant/repo", want: map[string]string{"org": "berquerant", "repo": "repo"}},
+		{name: "gh/berquerant"},
+		{name: "gh/berquerant/repo/x"},
+		{name: "gl/berquerant/repo"},
+		{name: "gh//repo"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := p.Match(tc.name)
+			if ok != (tc.want != nil) {
+				t.Fatalf("want match %v, got %v", tc.want != nil, ok)
+			}
+			if ok && !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPatternOverlaps(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{a: "gh/{org}/{repo}", b: "gh/{owner}/{name}", want: true},
+		{a: "gh/{org}/{repo}", b: "gh/berquerant/{repo}", want: true},
+		{a: "gh/{org}/issues", b: "gh/{org}/pulls", want: false},
+		{a: "gh/{org}/{repo}", b: "gh/{org}", want: false},
+		{a: "gh/{org}", b: "gl/{org}", want: false},
+	} {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			a, err := api.ParsePattern(tc.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := api.ParsePattern(tc.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Overlaps(b); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	params := map[string]string{
+		"org":  "berquerant",
+		"repo": "a b",
+	}
+	for _, tc := range []struct {
+		template string
+		want     string
+		err      bool
+	}{
+		{template: "https://github.com/{org}/{repo}", want: "https://github.com/berquerant/a%20b"},
+		{template: "https://example.com/", want: "https://example.com/"},
+		{template: "https://example.com/search?q={repo}", want: "https://example.com/search?q=a+b"},
+		{template: "https://example.com/{org}#{repo}", want: "https://example.com/berquerant#a+b"},
+		{template: "https://github.com/{org", err: true},
+		{template: "https://github.com/org}", err: true},
+		{template: "https://github.com/{user}", err: true},
+	} {
+		t.Run(tc.template, func(t *testing.T) {
+			got, err := api.ExpandTemplate(tc.template, params)
+			if tc.err {
+				if !errors.Is(err, api.ErrInvalidPattern) {
+					t.Fatalf("want ErrInvalidPattern, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Record struct {
+	// Namespace separates the records of the teams, DefaultNamespace if empty.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	To        string `json:"to"`
+	// StatusCode is the HTTP status code of the redirect, DefaultStatusCode if zero.
+	StatusCode int `json:"status_code,omitempty"`
+	// Match is how the record matches the requested name, MatchExact if empty.
+	Match MatchMode `json:"match,omitempty"`
+	// Query is what to do with the query string of the request, QueryDrop if empty.
+	Query QueryPolicy `json:"query,omitempty"`
+	// NotBefore is when the record starts redirecting, no limit if nil.
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	// ExpiresAt is when the record stops redirecting, no limit if nil.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Version is set by the server, starting from 1 and incremented by every put of the record.
+	Version int64 `json:"version,omitempty"`
+}
+
+// IsActive reports whether the record redirects at the time.
+func (r *Record) IsActive(now time.Time) bool {
+	return !r.IsPending(now) && !r.IsExpired(now)
+}
+
+// IsPending reports whether the record does not redirect yet at the time.
+func (r *Record) IsPending(now time.Time) bool {
+	return r.NotBefore != nil && now.Before(*r.NotBefore)
+}
+
+// IsExpired reports whether the record no longer redirects at the time.
+func (r *Record) IsExpired(now time.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
+}
+
+type MatchMode string
+
+const (
+	// MatchExact matches the name itself.
+	MatchExact MatchMode = "exact"
+	// MatchPrefix also matches the names under the record name, appending the rest to the redirect-to.
+	MatchPrefix MatchMode = "prefix"
+)
+
+// MatchModes are the match modes a record can have.
+var MatchModes = []MatchMode{
+	MatchExact,
+	MatchPrefix,
+}
+
+type QueryPolicy string
+
+const (
+	// QueryDrop discards the query string of the request.
+	QueryDrop QueryPolicy = "drop"
+	// QueryPass appends the query string of the request to the one of the redirect-to as is.
+	QueryPass QueryPolicy = "pass"
+	// QueryMergeRequest merges the query of the request into the redirect-to, the request wins.
+	QueryMergeRequest QueryPolicy = "merge_request"
+	// QueryMergeTarget merges the query of the request into the redirect-to, the redirect-to wins.
+	QueryMergeTarget QueryPolicy = "merge_target"
+)
+
+// QueryPolicies are the query policies a record can have.
+var QueryPolicies = []QueryPolicy{
+	QueryDrop,
+	QueryPass,
+	QueryMergeRequest,
+	QueryMergeTarget,
+}
+
+// QueryPolicy returns what to do with the query string of the request.
+func (r *Record) QueryPolicy() QueryPolicy {
+	if r.Query == "" {
+		return QueryDrop
+	}
+	return r.Query
+}
+
+// MatchMode returns how the record matches the requested name.
+func (r *Record) MatchMode() MatchMode {
+	if r.Match == "" {
+		return MatchExact
+	}
+	return r.Match
+}
+
+var (
+	ErrInvalidRecord = errors.New("InvalidRecord")
+	// ErrRecordGone is returned when redirecting with an expired record.
+	ErrRecordGone = errors.New("RecordGone")
+	// ErrConflict is returned when the precondition of a change does not hold.
+	ErrConflict = errors.New("Conflict")
+)
+
+// Precondition makes a change of a record conditional on the current record.
+type Precondition struct {
+	// IfVersion changes the record only if it exists at the version, unconditionally if zero.
+	IfVersion int64 `json:"if_version,omitempty"`
+	// IfAbsent puts the record only if it does not exist.
+	IfAbsent bool `json:"if_absent,omitempty"`
+}
+
+func (p Precondition) Validate() error {
+	if p.IfAbsent && p.IfVersion != 0 {
+		return fmt.Errorf("%w, if_absent with if_version %d", ErrInvalidRecord, p.IfVersion)
+	}
+	if p.IfVersion < 0 {
+		return fmt.Errorf("%w, if_version %d is negative", ErrInvalidRecord, p.IfVersion)
+	}
+	return nil
+}
+
+// Check returns ErrConflict if the current record, nil if absent, does not satisfy the precondition.
+func (p Precondition) Check(name string, current *Record) error {
+	switch {
+	case p.IfAbsent && current != nil:
+		return fmt.Errorf("%w, %s exists at version %d", ErrConflict, name, current.Version)
+	case p.IfVersion != 0 && current == nil:
+		return fmt.Errorf("%w, %s does not exist", ErrConflict, name)
+	case p.IfVersion != 0 && current.Version != p.IfVersion:
+		return fmt.Errorf("%w, %s is at version %d, not %d", ErrConflict, name, current.Version, p.IfVersion)
+	default:
+		return nil
+	}
+}
+
+// DefaultStatusCode is the status code of the redirect when a record does not specify it.
+const DefaultStatusCode = http.StatusMovedPermanently
+
+// StatusCodes are the status codes a record can redirect with.
+var StatusCodes = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusSeeOther,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// RedirectStatusCode returns the status code of the redirect.
+func (r *Record) RedirectStatusCode() int {
+	if r.StatusCode == 0 {
+		return DefaultStatusCode
+	}
+	return r.StatusCode
+}
+
+const (
+	// MaxNameLength is the maximum length of the name of a record in bytes.
+	MaxNameLength = 256
+	// MaxToLength is the maximum length of the redirect-to of a record in bytes.
+	MaxToLength = 2048
+)
+
+// ValidateName checks that the name is a path of alphanumerics, -._~/ and braces without the leading slash.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w, empty name", ErrInvalidRecord)
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("%w, name %s is longer than %d", ErrInvalidRecord, name, MaxNameLength)
+	case strings.HasPrefix(name, "/"):
+		return fmt.Errorf("%w, name %s starts with a slash", ErrInvalidRecord, name)
+	}
+	for _, c := range name {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.ContainsRune("-._~/{}", c):
+		default:
+			return fmt.Errorf("%w, name %s must consist of alphanumerics and -._~/{}", ErrInvalidRecord, name)
+		}
+	}
+	return nil
+}
+
+// templateParam matches the {param} of a redirect-to template.
+var templateParam = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateTo checks that the redirect-to is an absolute http or https URL once the parameters are expanded.
+func ValidateTo(to string) error {
+	if len(to) > MaxToLength {
+		return fmt.Errorf("%w, to %s is longer than %d", ErrInvalidRecord, to, MaxToLength)
+	}
+	u, err := url.Parse(templateParam.ReplaceAllString(to, "param"))
+	if err != nil {
+		return fmt.Errorf("%w, to %s is not a URL", ErrInvalidRecord, to)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w, to %s is not an absolute http or https URL", ErrInvalidRecord, to)
+	}
+	return nil
+}
+
+// Validate returns an error wrapping ErrInvalidRecord if the record cannot be stored.
+func (r *Record) Validate() error {
+	if err := ValidateNamespace(r.Namespace); err != nil {
+		return err
+	}
+	if err := ValidateName(r.Name); err != nil {
+		return err
+	}
+	if err := ValidateTo(r.To); err != nil {
+		return err
+	}
+	if r.StatusCode != 0 && !slices.Contains(StatusCodes, r.StatusCode) {
+		return fmt.Errorf("%w, status code %d is not one of %v", ErrInvalidRecord, r.StatusCode, StatusCodes)
+	}
+	if r.Match != "" && !slices.Contains(MatchModes, r.Match) {
+		return fmt.Errorf("%w, match %s is not one of %v", ErrInvalidRecord, r.Match, MatchModes)
+	}
+	if r.Query != "" && !slices.Contains(QueryPolicies, r.Query) {
+		return fmt.Errorf("%w, query %s is not one of %v", ErrInvalidRecord, r.Query, QueryPolicies)
+	}
+	if r.NotBefore != nil && r.ExpiresAt != nil && !r.NotBefore.Before(*r.ExpiresAt) {
+		return fmt.Errorf("%w, not_before %s is not before expires_at %s",
+			ErrInvalidRecord, r.NotBefore.Format(time.RFC3339), r.ExpiresAt.Format(time.RFC3339))
+	}
+	if IsPattern(r.Name) {
+		if r.MatchMode() != MatchExact {
+			return fmt.Errorf("%w, pattern %s must match exactly", ErrInvalidRecord, r.Name)
+		}
+		if err := ValidatePattern(r.Name, r.To); err != nil {
+			return fmt.Errorf("%w, %w", ErrInvalidRecord, err)
+		}
+	}
+	return nil
+}
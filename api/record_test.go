@@ -0,0 +1,78 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestValidateName(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  bool
+	}{
+		{name: "docs"},
+		{name: "docs/Go_1.21~rc-1"},
+		{name: "gh/{org}/{repo}"},
+		{name: strings.Repeat("a", api.MaxNameLength)},
+		{name: "", err: true},
+		{name: strings.Repeat("a", api.MaxNameLength+1), err: true},
+		{name: "/docs", err: true},
+		{name: "do cs", err: true},
+		{name: "<b>", err: true},
+		{name: "docs?q=1", err: true},
+		{name: "döcs", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := api.ValidateName(tc.name)
+			if tc.err != errors.Is(err, api.ErrInvalidRecord) {
+				t.Errorf("want error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateTo(t *testing.T) {
+	for _, tc := range []struct {
+		to  string
+		err bool
+	}{
+		{to: "https://example.com"},
+		{to: "http://example.com/docs?q=1#top"},
+		{to: "https://github.com/{org}/{repo}"},
+		{to: "https://{sub}.example.com/"},
+		{to: "", err: true},
+		{to: "example.com/docs", err: true},
+		{to: "/docs", err: true},
+		{to: "ftp://example.com", err: true},
+		{to: "javascript:alert(1)", err: true},
+		{to: "https://", err: true},
+		{to: "https://example.com/" + strings.Repeat("a", api.MaxToLength), err: true},
+	} {
+		t.Run(tc.to, func(t *testing.T) {
+			err := api.ValidateTo(tc.to)
+			if tc.err != errors.Is(err, api.ErrInvalidRecord) {
+				t.Errorf("want error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestPutHandlerInvalidRecord(t *testing.T) {
+	server := newTestServer(t)
+	handler := api.API(server.Put)
+	for _, body := range []string{
+		`{"record":{"name":"/docs","to":"https://example.com"}}`,
+		`{"record":{"name":"docs","to":"example.com"}}`,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/put", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: want %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("RevisionNotFound")
+)
+
+// RevisionOp is the change of a revision.
+type RevisionOp string
+
+const (
+	RevisionOpPut    RevisionOp = "put"
+	RevisionOpDelete RevisionOp = "delete"
+)
+
+// Revision is a change of a record.
+type Revision struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Revision is the sequence number of the revisions of the record, starting from 1.
+	Revision int        `json:"revision"`
+	Op       RevisionOp `json:"op"`
+	Time     time.Time  `json:"time"`
+	// Actor is the name of the token of the change, empty if the tokens are disabled.
+	Actor string `json:"actor,omitempty"`
+	// Old is the record before the change, nil if the record did not exist.
+	Old *Record `json:"old,omitempty"`
+	// New is the record after the change, nil if the record was deleted.
+	New *Record `json:"new,omitempty"`
+	// Rollback is the revision the change rolled back to, zero if the change is not a rollback.
+	Rollback int `json:"rollback,omitempty"`
+}
+
+// RevisionStore persists the revisions.
+type RevisionStore interface {
+	// Add numbers the revision and stores it.
+	Add(ctx context.Context, revision *Revision) error
+	// List returns the revisions of the record in order, empty if none.
+	List(ctx context.Context, namespace, name string) ([]*Revision, error)
+	Get(ctx context.Context, namespace, name string, revision int) (*Revision, error)
+}
+
+// revisionIndex keeps the revisions in memory by recordKey.
+type revisionIndex struct {
+	revisions map[string][]*Revision
+	mux       sync.RWMutex
+}
+
+func newRevisionIndex() *revisionIndex {
+	return &revisionIndex{
+		revisions: map[string][]*Revision{},
+	}
+}
+
+// next returns the number of the next revision of the record.
+func (x *revisionIndex) next(namespace, name string) int {
+	return len(x.revisions[recordKey(namespace, name)]) + 1
+}
+
+func (x *revisionIndex) add(revision *Revision) {
+	key := recordKey(revision.Namespace, revision.Name)
+	x.revisions[key] = append(x.revisions[key], revision)
+}
+
+func (x *revisionIndex) list(namespace, name string) []*Revision {
+	x.mux.RLock()
+	defer x.mux.RUnlock()
+	return append([]*Revision{}, x.revisions[recordKey(namespace, name)]...)
+}
+
+func (x *revisionIndex) get(namespace, name string, revision int) (*Revision, error) {
+	x.mux.RLock()
+	defer x.mux.RUnlock()
+	rs := x.revisions[recordKey(namespace, name)]
+	if revision < 1 || revision > len(rs) {
+		return nil, fmt.Errorf("%w, %s revision %d", ErrRevisionNotFound, qualifiedName(namespace, name), revision)
+	}
+	return rs[revision-1], nil
+}
+
+// NewRevisionMemory returns a RevisionStore that does not persist the revisions.
+func NewRevisionMemory() RevisionStore {
+	return &revisionMemory{
+		index: newRevisionIndex(),
+	}
+}
+
+type revisionMemory struct {
+	index *revisionIndex
+}
+
+func (m *revisionMemory) Add(_ context.Context, revision *Revision) error {
+	m.index.mux.Lock()
+	defer m.index.mux.Unlock()
+	revision.Revision = m.index.next(revision.Namespace, revision.Name)
+	m.index.add(revision)
+	return nil
+}
+
+func (m *revisionMemory) List(_ context.Context, namespace, name string) ([]*Revision, error) {
+	return m.index.list(namespace, name), nil
+}
+
+func (m *revisionMemory) Get(_ context.Context, namespace, name string, revision int) (*Revision, error) {
+	return m.index.get(namespace, name, revision)
+}
+
+// NewRevisionFile returns a RevisionStore on the JSON Lines file, indexing the revisions in it on open.
+func NewRevisionFile(filename string) (RevisionStore, error) {
+	lines, err := readJSONLines(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w, read revisions %v", ErrReadDatabase, err)
+	}
+	index := newRevisionIndex()
+	for _, line := range lines {
+		var r Revision
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("%w, unmarshal revisions", ErrReadDatabase)
+		}
+		index.add(&r)
+	}
+	return &revisionFile{
+		filename: filename,
+		index:    index,
+	}, nil
+}
+
+type revisionFile struct {
+	filename string
+	index    *revisionIndex
+}
+
+func (f *revisionFile) Add(_ context.Context, revision *Revision) error {
+	f.index.mux.Lock()
+	defer f.index.mux.Unlock()
+	revision.Revision = f.index.next(revision.Namespace, revision.Name)
+	if err := appendJSONLines(f.filename, []*Revision{revision}); err != nil {
+		return fmt.Errorf("%w, write revisions %v", ErrWriteDatabase, err)
+	}
+	f.index.add(revision)
+	return nil
+}
+
+func (f *revisionFile) List(_ context.Context, namespace, name string) ([]*Revision, error) {
+	return f.index.list(namespace, name), nil
+}
+
+func (f *revisionFile) Get(_ context.Context, namespace, name string, revision int) (*Revision, error) {
+	return f.index.get(namespace, name, revision)
+}
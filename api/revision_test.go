@@ -0,0 +1,175 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestServerImplHistory(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	for _, to := range []string{"https://example.com/1", "https://example.com/2"} {
+		if _, err := server.Put(ctx, &api.PutRequest{Record: &api.Record{Name: "x", To: to}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := server.Delete(ctx, &api.DeleteRequest{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := server.History(ctx, &api.HistoryRequest{Name: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Revisions) != 3 {
+		t.Fatalf("want 3 revisions, got %d", len(got.Revisions))
+	}
+	for i, want := range []struct {
+		op  api.RevisionOp
+		old string
+		new string
+	}{
+		{op: api.RevisionOpPut, new: "https://example.com/1"},
+		{op: api.RevisionOpPut, old: "https://example.com/1", new: "https://example.com/2"},
+		{op: api.RevisionOpDelete, old: "https://example.com/2"},
+	} {
+		r := got.Revisions[i]
+		if r.Revision != i+1 || r.Op != want.op || recordTo(r.Old) != want.old || recordTo(r.New) != want.new {
+			t.Errorf("revision %d: want %v, got %+v", i+1, want, r)
+		}
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		got, err := server.Rollback(ctx, &api.RollbackRequest{Name: "x", Revision: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Record.To != "https://example.com/1" {
+			t.Errorf("want https://example.com/1, got %s", got.Record.To)
+		}
+		h, _ := server.History(ctx, &api.HistoryRequest{Name: "x"})
+		last := h.Revisions[len(h.Revisions)-1]
+		if last.Revision != 4 || last.Rollback != 1 || last.Old != nil {
+			t.Errorf("want revision 4 rolling back to 1, got %+v", last)
+		}
+	})
+
+	t.Run("rollback to delete", func(t *testing.T) {
+		if _, err := server.Rollback(ctx, &api.RollbackRequest{Name: "x", Revision: 3}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := server.Get(ctx, &api.GetRequest{Name: "x"}); !errors.Is(err, api.ErrRecordNotFound) {
+			t.Errorf("want ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		if _, err := server.Rollback(ctx, &api.RollbackRequest{Name: "x", Revision: 10}); !errors.Is(err, api.ErrRevisionNotFound) {
+			t.Errorf("want ErrRevisionNotFound, got %v", err)
+		}
+	})
+
+	t.Run("no revisions", func(t *testing.T) {
+		got, err := server.History(ctx, &api.HistoryRequest{Name: "y"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Revisions) != 0 {
+			t.Errorf("want no revisions, got %v", got.Revisions)
+		}
+	})
+}
+
+func TestClientImplHistory(t *testing.T) {
+	tokens, err := api.NewTokens([]*api.TokenEntry{
+		{Name: "ci", Scope: api.ScopeReadWrite, Hash: api.HashToken("ci-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/put", tokens.Require(api.ScopeReadWrite, api.API(server.Put)))
+	mux.HandleFunc("/history", tokens.Require(api.ScopeReadOnly, api.API(server.History)))
+	mux.HandleFunc("/rollback", tokens.Require(api.ScopeReadWrite, api.API(server.Rollback)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := api.NewClientImpl(ts.URL, &http.Client{
+		Transport: api.NewTokenTransport("ci-token", nil),
+	}).WithNamespace("team")
+	if _, err := client.Put(ctx, &api.Record{Name: "x", To: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.History(ctx, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Actor != "ci" || got[0].Namespace != "team" {
+		t.Errorf("want 1 revision of ci in team, got %v", got)
+	}
+	if _, err := client.Rollback(ctx, "x", 2); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+}
+
+func TestRevisionFile(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		filename = filepath.Join(t.TempDir(), "history.jsonl")
+	)
+	store, err := api.NewRevisionFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []*api.Revision{
+		{Name: "x", Op: api.RevisionOpPut, New: &api.Record{Name: "x", To: "https://example.com/1"}},
+		{Namespace: "go", Name: "x", Op: api.RevisionOpPut, New: &api.Record{Namespace: "go", Name: "x", To: "https://example.com/go"}},
+		{Name: "x", Op: api.RevisionOpDelete, Old: &api.Record{Name: "x", To: "https://example.com/1"}},
+	} {
+		if err := store.Add(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := api.NewRevisionFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Add(ctx, &api.Revision{Name: "x", Op: api.RevisionOpPut, New: &api.Record{Name: "x", To: "https://example.com/2"}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.List(ctx, api.DefaultNamespace, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var revisions []int
+	for _, r := range got {
+		revisions = append(revisions, r.Revision)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(want, revisions) {
+		t.Errorf("want %v, got %v", want, revisions)
+	}
+	r, err := reopened.Get(ctx, "go", "x", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.New.To != "https://example.com/go" {
+		t.Errorf("want https://example.com/go, got %s", r.New.To)
+	}
+}
+
+func recordTo(r *api.Record) string {
+	if r == nil {
+		return ""
+	}
+	return r.To
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultScanPageSize is the number of the records the client requests per scan page.
+const DefaultScanPageSize = 500
+
+var (
+	ErrInvalidScan = errors.New("InvalidScan")
+)
+
+// ScanFilter selects the records of a scan by their name and redirect-to.
+type ScanFilter struct {
+	// NamePrefix selects the records whose name starts with it, all if empty.
+	NamePrefix string `json:"name_prefix,omitempty"`
+	// ToContains selects the records whose to contains it, all if empty.
+	ToContains string `json:"to_contains,omitempty"`
+	// ToRegexp selects the records whose to matches the regular expression, all if empty.
+	ToRegexp string `json:"to_regexp,omitempty"`
+}
+
+// matcher returns the function reporting whether the filter selects a record.
+func (f *ScanFilter) matcher() (func(*Record) bool, error) {
+	var re *regexp.Regexp
+	if f.ToRegexp != "" {
+		var err error
+		if re, err = regexp.Compile(f.ToRegexp); err != nil {
+			return nil, fmt.Errorf("%w, to_regexp %v", ErrInvalidScan, err)
+		}
+	}
+	return func(r *Record) bool {
+		switch {
+		case !strings.HasPrefix(r.Name, f.NamePrefix):
+			return false
+		case !strings.Contains(r.To, f.ToContains):
+			return false
+		case re != nil && !re.MatchString(r.To):
+			return false
+		default:
+			return true
+		}
+	}, nil
+}
+
+// sortRecords sorts the records by namespace and name, the order of the scan pages.
+func sortRecords(records []*Record) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Namespace != records[j].Namespace {
+			return records[i].Namespace < records[j].Namespace
+		}
+		return records[i].Name < records[j].Name
+	})
+}
+
+// encodeContinuationToken returns the token of the scan page after the record.
+func encodeContinuationToken(r *Record) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(r.Namespace + "\x00" + r.Name))
+}
+
+// decodeContinuationToken returns the namespace and name of the last record of the previous scan page.
+func decodeContinuationToken(token string) (string, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", fmt.Errorf("%w, continuation_token %s", ErrInvalidScan, token)
+	}
+	namespace, name, ok := strings.Cut(string(b), "\x00")
+	if !ok {
+		return "", "", fmt.Errorf("%w, continuation_token %s", ErrInvalidScan, token)
+	}
+	return namespace, name, nil
+}
+
+// page returns at most limit records after the token, all if not positive, and the next token.
+func page(records []*Record, token string, limit int) ([]*Record, string, error) {
+	if limit < 0 {
+		return nil, "", fmt.Errorf("%w, negative limit %d", ErrInvalidScan, limit)
+	}
+	if token != "" {
+		namespace, name, err := decodeContinuationToken(token)
+		if err != nil {
+			return nil, "", err
+		}
+		start := sort.Search(len(records), func(i int) bool {
+			r := records[i]
+			return r.Namespace > namespace || (r.Namespace == namespace && r.Name > name)
+		})
+		records = records[start:]
+	}
+	if limit == 0 || len(records) <= limit {
+		return records, "", nil
+	}
+	records = records[:limit]
+	return records, encodeContinuationToken(records[limit-1]), nil
+}
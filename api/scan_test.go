@@ -0,0 +1,173 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestServerImplScanFilter(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "docs/b", To: "https://example.com/b"},
+		&api.Record{Name: "docs/a", To: "https://example.org/a"},
+		&api.Record{Name: "blog", To: "https://example.com/blog"},
+	)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		title  string
+		filter api.ScanFilter
+		want   []string
+		err    error
+	}{
+		{
+			title: "all sorted",
+			want:  []string{"blog", "docs/a", "docs/b"},
+		},
+		{
+			title:  "name prefix",
+			filter: api.ScanFilter{NamePrefix: "docs/"},
+			want:   []string{"docs/a", "docs/b"},
+		},
+		{
+			title:  "to contains",
+			filter: api.ScanFilter{ToContains: "example.com"},
+			want:   []string{"blog", "docs/b"},
+		},
+		{
+			title:  "to regexp",
+			filter: api.ScanFilter{ToRegexp: `\.org/`},
+			want:   []string{"docs/a"},
+		},
+		{
+			title:  "all filters",
+			filter: api.ScanFilter{NamePrefix: "docs/", ToContains: "example", ToRegexp: `/b$`},
+			want:   []string{"docs/b"},
+		},
+		{
+			title:  "no match",
+			filter: api.ScanFilter{NamePrefix: "none"},
+			err:    api.ErrRecordNotFound,
+		},
+		{
+			title:  "invalid regexp",
+			filter: api.ScanFilter{ToRegexp: "("},
+			err:    api.ErrInvalidScan,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			res, err := server.Scan(ctx, &api.ScanRequest{ScanFilter: tc.filter})
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("want %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := names(res.Records); !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServerImplScanPage(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "e", To: "https://example.com/e"},
+		&api.Record{Name: "d", To: "https://example.com/d"},
+		&api.Record{Name: "c", To: "https://example.com/c"},
+		&api.Record{Name: "b", To: "https://example.com/b"},
+		&api.Record{Name: "a", To: "https://example.com/a"},
+	)
+	ctx := context.Background()
+
+	var (
+		got   []string
+		token string
+	)
+	for i := 0; ; i++ {
+		if i > 3 {
+			t.Fatal("want 3 pages")
+		}
+		res, err := server.Scan(ctx, &api.ScanRequest{Limit: 2, ContinuationToken: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Records) > 2 {
+			t.Errorf("want at most 2 records, got %d", len(res.Records))
+		}
+		got = append(got, names(res.Records)...)
+		if res.ContinuationToken == "" {
+			break
+		}
+		token = res.ContinuationToken
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	t.Run("invalid token", func(t *testing.T) {
+		if _, err := server.Scan(ctx, &api.ScanRequest{Limit: 2, ContinuationToken: "!"}); !errors.Is(err, api.ErrInvalidScan) {
+			t.Errorf("want ErrInvalidScan, got %v", err)
+		}
+	})
+	t.Run("negative limit", func(t *testing.T) {
+		if _, err := server.Scan(ctx, &api.ScanRequest{Limit: -1}); !errors.Is(err, api.ErrInvalidScan) {
+			t.Errorf("want ErrInvalidScan, got %v", err)
+		}
+	})
+}
+
+func TestClientImplScanFiltered(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "docs/c", To: "https://example.com/c"},
+		&api.Record{Name: "docs/b", To: "https://example.com/b"},
+		&api.Record{Name: "docs/a", To: "https://example.com/a"},
+		&api.Record{Name: "blog", To: "https://example.com/blog"},
+	)
+	var requests int
+	mux := http.NewServeMux()
+	scan := api.API(server.Scan)
+	mux.HandleFunc("/scan", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		scan(w, r)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := api.NewClientImpl(ts.URL, http.DefaultClient)
+	client.SetScanPageSize(2)
+	got, err := client.ScanFiltered(ctx, api.ScanFilter{NamePrefix: "docs/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docs/a", "docs/b", "docs/c"}; !reflect.DeepEqual(want, names(got)) {
+		t.Errorf("want %v, got %v", want, names(got))
+	}
+	if requests != 2 {
+		t.Errorf("want 2 pages, got %d", requests)
+	}
+
+	got, err = client.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Errorf("want 4 records, got %d", len(got))
+	}
+	if _, err := client.ScanFiltered(ctx, api.ScanFilter{ToRegexp: "("}); !errors.Is(err, api.ErrBadRequest) {
+		t.Errorf("want ErrBadRequest, got %v", err)
+	}
+}
+
+func names(records []*api.Record) []string {
+	ns := make([]string, len(records))
+	for i, r := range records {
+		ns[i] = r.Name
+	}
+	return ns
+}
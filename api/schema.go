@@ -1,14 +1,29 @@
 package api
 
+import "time"
+
 type (
-	ScanRequest  struct{}
+	ScanRequest struct {
+		// Namespace selects the records of the namespace.
+		Namespace string `json:"namespace,omitempty"`
+		// AllNamespaces selects the records of all the namespaces the token can access instead.
+		AllNamespaces bool `json:"all_namespaces,omitempty"`
+		ScanFilter
+		// Limit is the maximum number of the records of the response, all if zero.
+		Limit int `json:"limit,omitempty"`
+		// ContinuationToken selects the records after the previous response.
+		ContinuationToken string `json:"continuation_token,omitempty"`
+	}
 	ScanResponse struct {
 		Records []*Record `json:"records,omitempty"`
-		Error   string    `json:"error,omitempty"`
+		// ContinuationToken is the token of the next request, empty if there are no more records.
+		ContinuationToken string `json:"continuation_token,omitempty"`
+		Error             string `json:"error,omitempty"`
 	}
 
 	GetRequest struct {
-		Name string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
 	}
 	GetResponse struct {
 		Record *Record `json:"record,omitempty"`
@@ -17,6 +32,7 @@ type (
 
 	PutRequest struct {
 		Record *Record `json:"record"`
+		Precondition
 	}
 	PutResponse struct {
 		Record *Record `json:"record,omitempty"`
@@ -24,17 +40,129 @@ type (
 	}
 
 	DeleteRequest struct {
-		Name string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+		Precondition
 	}
 	DeleteResponse struct {
 		Error string `json:"error,omitempty"`
 	}
 
 	RedirectRequest struct {
-		Name string `json:"name"`
+		// Namespace is the namespace of the name, taken from the path if empty.
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+		// Host is the host of the request selecting the namespace by the domains.
+		Host string `json:"host,omitempty"`
+		// Query is the raw query string of the request.
+		Query string `json:"query,omitempty"`
+		// Referrer and UserAgent are the headers of the request for the analytics.
+		Referrer  string `json:"referrer,omitempty"`
+		UserAgent string `json:"user_agent,omitempty"`
 	}
 	RedirectResponse struct {
-		To    string `json:"to"`
+		To string `json:"to"`
+		// StatusCode is the status of the redirect, Record.RedirectStatusCode of the record.
+		StatusCode int `json:"status_code,omitempty"`
+		// Suggestions are the names close to the unknown name.
+		Suggestions []string `json:"suggestions,omitempty"`
+		Error       string   `json:"error,omitempty"`
+	}
+
+	GetFallbackRequest  struct{}
+	GetFallbackResponse struct {
+		Fallback *Fallback `json:"fallback,omitempty"`
+		Error    string    `json:"error,omitempty"`
+	}
+
+	PutFallbackRequest struct {
+		Fallback *Fallback `json:"fallback"`
+	}
+	PutFallbackResponse struct {
+		Fallback *Fallback `json:"fallback,omitempty"`
+		Error    string    `json:"error,omitempty"`
+	}
+
+	StatsRequest struct {
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+	}
+	StatsResponse struct {
+		Stats *Stats `json:"stats,omitempty"`
+		Error string `json:"error,omitempty"`
+	}
+
+	ScanDomainsRequest  struct{}
+	ScanDomainsResponse struct {
+		Domains []*Domain `json:"domains,omitempty"`
+		Error   string    `json:"error,omitempty"`
+	}
+
+	GetDomainRequest struct {
+		Host string `json:"host"`
+	}
+	GetDomainResponse struct {
+		Domain *Domain `json:"domain,omitempty"`
+		Error  string  `json:"error,omitempty"`
+	}
+
+	PutDomainRequest struct {
+		Domain *Domain `json:"domain"`
+	}
+	PutDomainResponse struct {
+		Domain *Domain `json:"domain,omitempty"`
+		Error  string  `json:"error,omitempty"`
+	}
+
+	DeleteDomainRequest struct {
+		Host string `json:"host"`
+	}
+	DeleteDomainResponse struct {
 		Error string `json:"error,omitempty"`
 	}
+
+	HistoryRequest struct {
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+	}
+	HistoryResponse struct {
+		// Revisions are the changes of the record, oldest first.
+		Revisions []*Revision `json:"revisions"`
+		Error     string      `json:"error,omitempty"`
+	}
+
+	RollbackRequest struct {
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name"`
+		Revision  int    `json:"revision"`
+	}
+	RollbackResponse struct {
+		// Record is the restored record, nil if the rollback deleted the record.
+		Record *Record `json:"record,omitempty"`
+		Error  string  `json:"error,omitempty"`
+	}
+
+	BatchRequest struct {
+		Operations []*BatchOperation `json:"operations"`
+	}
+	BatchResponse struct {
+		// Records are the records of the operations in order with their versions, nil for the deletes.
+		Records []*Record `json:"records,omitempty"`
+		Error   string    `json:"error,omitempty"`
+	}
+
+	AuditRequest struct {
+		// Since selects the entries at or after the time, all if nil.
+		Since *time.Time `json:"since,omitempty"`
+		// Until selects the entries before the time, all if nil.
+		Until *time.Time `json:"until,omitempty"`
+		// Namespace and Name select the entries of the record, all if Name is empty.
+		Namespace string `json:"namespace,omitempty"`
+		Name      string `json:"name,omitempty"`
+	}
+	AuditResponse struct {
+		// Entries are the calls, oldest first.
+		Entries []*AuditEntry `json:"entries"`
+		Error   string        `json:"error,omitempty"`
+	}
 )
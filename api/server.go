@@ -1,6 +1,13 @@
 package api
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
 
 var (
 	_ Server     = NewServerImpl(nil)
@@ -12,6 +19,17 @@ type Server interface {
 	Get(ctx context.Context, r *GetRequest) (*GetResponse, error)
 	Put(ctx context.Context, r *PutRequest) (*PutResponse, error)
 	Delete(ctx context.Context, r *DeleteRequest) (*DeleteResponse, error)
+	GetFallback(ctx context.Context, r *GetFallbackRequest) (*GetFallbackResponse, error)
+	PutFallback(ctx context.Context, r *PutFallbackRequest) (*PutFallbackResponse, error)
+	Stats(ctx context.Context, r *StatsRequest) (*StatsResponse, error)
+	ScanDomains(ctx context.Context, r *ScanDomainsRequest) (*ScanDomainsResponse, error)
+	GetDomain(ctx context.Context, r *GetDomainRequest) (*GetDomainResponse, error)
+	PutDomain(ctx context.Context, r *PutDomainRequest) (*PutDomainResponse, error)
+	DeleteDomain(ctx context.Context, r *DeleteDomainRequest) (*DeleteDomainResponse, error)
+	History(ctx context.Context, r *HistoryRequest) (*HistoryResponse, error)
+	Rollback(ctx context.Context, r *RollbackRequest) (*RollbackResponse, error)
+	Audit(ctx context.Context, r *AuditRequest) (*AuditResponse, error)
+	Batch(ctx context.Context, r *BatchRequest) (*BatchResponse, error)
 }
 
 type Redirector interface {
@@ -21,27 +39,121 @@ type Redirector interface {
 func NewServerImpl(db Database) *ServerImpl {
 	return &ServerImpl{
 		db: db,
+		fallback: NewFallbackMemory(&Fallback{
+			Mode: FallbackNone,
+		}),
+		domains:   NewDomainMemory(),
+		revisions: NewRevisionMemory(),
+		audit:     NewAuditMemory(),
+		now:       time.Now,
 	}
 }
 
 type ServerImpl struct {
-	db Database
+	db       Database
+	fallback FallbackStore
+	domains  DomainStore
+	// revisions records the changes of the records
+	revisions RevisionStore
+	// audit records the calls changing the records
+	audit AuditLog
+	// hits records the redirects, nil if disabled
+	hits *HitRecorder
+	now  func() time.Time
+	// writeMux serializes the changes of the records for the preconditions and the versions
+	writeMux sync.Mutex
+	// goneOnExpired makes Redirect return ErrRecordGone instead of ErrRecordNotFound for expired records
+	goneOnExpired bool
+}
+
+// SetFallbackStore replaces the store of the fallback for unknown names.
+func (s *ServerImpl) SetFallbackStore(store FallbackStore) {
+	s.fallback = store
+}
+
+// SetDomainStore replaces the store of the domains selecting the namespaces by the hosts.
+func (s *ServerImpl) SetDomainStore(store DomainStore) {
+	s.domains = store
+}
+
+// SetRevisionStore replaces the store of the revisions of the records.
+func (s *ServerImpl) SetRevisionStore(store RevisionStore) {
+	s.revisions = store
+}
+
+// SetAuditLog replaces the log of the calls changing the records.
+func (s *ServerImpl) SetAuditLog(log AuditLog) {
+	s.audit = log
 }
 
-func (s *ServerImpl) Scan(ctx context.Context, _ *ScanRequest) (*ScanResponse, error) {
+// SetHitRecorder enables the analytics of the redirects.
+func (s *ServerImpl) SetHitRecorder(recorder *HitRecorder) {
+	s.hits = recorder
+}
+
+// SetGoneOnExpired makes the redirect of an expired record 410 Gone instead of 404 Not Found.
+func (s *ServerImpl) SetGoneOnExpired(gone bool) {
+	s.goneOnExpired = gone
+}
+
+// Scan returns the records the filter selects sorted by namespace and name, a page of them if the limit is set.
+func (s *ServerImpl) Scan(ctx context.Context, r *ScanRequest) (*ScanResponse, error) {
+	match, err := r.ScanFilter.matcher()
+	if err != nil {
+		return &ScanResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if !r.AllNamespaces {
+		if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+			return &ScanResponse{
+				Error: err.Error(),
+			}, err
+		}
+	}
 	records, err := s.db.Scan(ctx)
 	if err != nil {
 		return &ScanResponse{
 			Error: err.Error(),
 		}, err
 	}
+	var rs []*Record
+	for _, record := range records {
+		switch {
+		case !match(record):
+		case r.AllNamespaces:
+			if authorizeNamespace(ctx, record.Namespace) == nil {
+				rs = append(rs, record)
+			}
+		case record.Namespace == r.Namespace:
+			rs = append(rs, record)
+		}
+	}
+	sortRecords(rs)
+	rs, token, err := page(rs, r.ContinuationToken, r.Limit)
+	if err != nil {
+		return &ScanResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if len(rs) == 0 {
+		return &ScanResponse{
+			Error: ErrRecordNotFound.Error(),
+		}, ErrRecordNotFound
+	}
 	return &ScanResponse{
-		Records: records,
+		Records:           rs,
+		ContinuationToken: token,
 	}, nil
 }
 
 func (s *ServerImpl) Get(ctx context.Context, r *GetRequest) (*GetResponse, error) {
-	record, err := s.db.Get(ctx, r.Name)
+	if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+		return &GetResponse{
+			Error: err.Error(),
+		}, err
+	}
+	record, err := s.db.Get(ctx, r.Namespace, r.Name)
 	if err != nil {
 		return &GetResponse{
 			Error: err.Error(),
@@ -52,8 +164,37 @@ func (s *ServerImpl) Get(ctx context.Context, r *GetRequest) (*GetResponse, erro
 	}, nil
 }
 
-func (s *ServerImpl) Put(ctx context.Context, r *PutRequest) (*PutResponse, error) {
-	if err := s.db.Put(ctx, r.Record); err != nil {
+func (s *ServerImpl) Put(ctx context.Context, r *PutRequest) (_ *PutResponse, err error) {
+	defer func() {
+		entry := &AuditEntry{Op: AuditOpPut, Record: r.Record}
+		if r.Record != nil {
+			entry.Namespace, entry.Name = r.Record.Namespace, r.Record.Name
+		}
+		s.addAudit(ctx, entry, err)
+	}()
+	if r.Record == nil {
+		err := fmt.Errorf("%w, no record", ErrInvalidRecord)
+		return &PutResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := r.Record.Validate(); err != nil {
+		return &PutResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := r.Precondition.Validate(); err != nil {
+		return &PutResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := authorizeNamespace(ctx, r.Record.Namespace); err != nil {
+		return &PutResponse{
+			Error: err.Error(),
+		}, err
+	}
+	setRecordDefaults(r.Record)
+	if err := s.put(ctx, r.Record, r.Precondition, 0); err != nil {
 		return &PutResponse{
 			Error: err.Error(),
 		}, err
@@ -63,8 +204,22 @@ func (s *ServerImpl) Put(ctx context.Context, r *PutRequest) (*PutResponse, erro
 	}, nil
 }
 
-func (s *ServerImpl) Delete(ctx context.Context, r *DeleteRequest) (*DeleteResponse, error) {
-	if err := s.db.Delete(ctx, r.Name); err != nil {
+func (s *ServerImpl) Delete(ctx context.Context, r *DeleteRequest) (_ *DeleteResponse, err error) {
+	defer func() {
+		s.addAudit(ctx, &AuditEntry{Op: AuditOpDelete, Namespace: r.Namespace, Name: r.Name}, err)
+	}()
+	if r.IfAbsent {
+		err := fmt.Errorf("%w, if_absent on delete", ErrInvalidRecord)
+		return &DeleteResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+		return &DeleteResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := s.delete(ctx, r.Namespace, r.Name, r.Precondition, 0); err != nil {
 		return &DeleteResponse{
 			Error: err.Error(),
 		}, err
@@ -72,14 +227,691 @@ func (s *ServerImpl) Delete(ctx context.Context, r *DeleteRequest) (*DeleteRespo
 	return nil, nil
 }
 
+// setRecordDefaults fills the unset fields of the record to be put with their defaults.
+func setRecordDefaults(record *Record) {
+	if record.StatusCode == 0 {
+		record.StatusCode = DefaultStatusCode
+	}
+	if record.Match == "" {
+		record.Match = MatchExact
+	}
+	if record.Query == "" {
+		record.Query = QueryDrop
+	}
+}
+
+// Batch applies the operations in order atomically, all of them or none, in a single write of the database.
+func (s *ServerImpl) Batch(ctx context.Context, r *BatchRequest) (_ *BatchResponse, err error) {
+	defer func() {
+		for _, o := range r.Operations {
+			if o != nil {
+				s.addAudit(ctx, o.auditEntry(), err)
+			}
+		}
+	}()
+	if len(r.Operations) == 0 {
+		err := fmt.Errorf("%w, no operations", ErrInvalidRecord)
+		return &BatchResponse{
+			Error: err.Error(),
+		}, err
+	}
+	for i, o := range r.Operations {
+		err := fmt.Errorf("%w, no operation", ErrInvalidRecord)
+		if o != nil {
+			err = o.Validate()
+		}
+		if err == nil {
+			err = authorizeNamespace(ctx, namespaceOf(o))
+		}
+		if err != nil {
+			err = fmt.Errorf("%w, operation %d", err, i)
+			return &BatchResponse{
+				Error: err.Error(),
+			}, err
+		}
+		if o.Op == BatchOpPut {
+			setRecordDefaults(o.Record)
+		}
+	}
+	if err := s.batch(ctx, r.Operations); err != nil {
+		return &BatchResponse{
+			Error: err.Error(),
+		}, err
+	}
+	records := make([]*Record, len(r.Operations))
+	for i, o := range r.Operations {
+		records[i] = o.Record
+	}
+	return &BatchResponse{
+		Records: records,
+	}, nil
+}
+
+// namespaceOf returns the namespace of the record of the operation.
+func namespaceOf(o *BatchOperation) string {
+	if o.Op == BatchOpPut {
+		return o.Record.Namespace
+	}
+	return o.Namespace
+}
+
+// batch checks and writes the operations at once, recording their revisions.
+func (s *ServerImpl) batch(ctx context.Context, ops []*BatchOperation) error {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+	records, err := s.db.Scan(ctx)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+	current := make(map[string]*Record, len(records))
+	for _, r := range records {
+		current[r.key()] = r
+	}
+	revisions := make([]*Revision, len(ops))
+	for i, o := range ops {
+		key := o.key()
+		old := current[key]
+		if err := o.Precondition.Check(o.qualifiedName(), old); err != nil {
+			return fmt.Errorf("%w, operation %d", err, i)
+		}
+		if o.Op == BatchOpDelete {
+			if old == nil {
+				return fmt.Errorf("%w, %s, operation %d", ErrRecordNotFound, o.qualifiedName(), i)
+			}
+			delete(current, key)
+			revisions[i] = &Revision{Namespace: o.Namespace, Name: o.Name, Op: RevisionOpDelete, Old: old}
+			continue
+		}
+		o.Record.Version = 1
+		if old != nil {
+			o.Record.Version = old.Version + 1
+		}
+		put := *o.Record
+		current[key] = &put
+		revisions[i] = &Revision{Namespace: put.Namespace, Name: put.Name, Op: RevisionOpPut, Old: old, New: &put}
+	}
+
+	result, err := applyBatch(records, ops)
+	if err != nil {
+		return err
+	}
+	for i, o := range ops {
+		if o.Op != BatchOpPut || !IsPattern(o.Record.Name) || current[o.key()] == nil {
+			continue
+		}
+		p, err := ParsePattern(o.Record.Name)
+		if err != nil {
+			return fmt.Errorf("%w, %w, operation %d", ErrInvalidRecord, err, i)
+		}
+		if err := patternConflict(p, o.Record, result); err != nil {
+			return fmt.Errorf("%w, operation %d", err, i)
+		}
+	}
+
+	if err := s.db.Batch(ctx, ops); err != nil {
+		return err
+	}
+	for _, r := range revisions {
+		s.addRevision(ctx, r)
+	}
+	return nil
+}
+
+// put puts the record at the next version, recording the revision of the rollback if not zero.
+func (s *ServerImpl) put(ctx context.Context, record *Record, precondition Precondition, rollback int) error {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+	if err := s.checkPatternConflict(ctx, record); err != nil {
+		return err
+	}
+	old, err := s.db.Get(ctx, record.Namespace, record.Name)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+	if err := precondition.Check(qualifiedName(record.Namespace, record.Name), old); err != nil {
+		return err
+	}
+	record.Version = 1
+	if old != nil {
+		record.Version = old.Version + 1
+	}
+	if err := s.db.Put(ctx, record); err != nil {
+		return err
+	}
+	put := *record
+	s.addRevision(ctx, &Revision{
+		Namespace: record.Namespace,
+		Name:      record.Name,
+		Op:        RevisionOpPut,
+		Old:       old,
+		New:       &put,
+		Rollback:  rollback,
+	})
+	return nil
+}
+
+// delete deletes the record if the precondition holds, and records the revision.
+func (s *ServerImpl) delete(ctx context.Context, namespace, name string, precondition Precondition, rollback int) error {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+	old, err := s.db.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := precondition.Check(qualifiedName(namespace, name), old); err != nil {
+		return err
+	}
+	if err := s.db.Delete(ctx, namespace, name); err != nil {
+		return err
+	}
+	s.addRevision(ctx, &Revision{
+		Namespace: namespace,
+		Name:      name,
+		Op:        RevisionOpDelete,
+		Old:       old,
+		Rollback:  rollback,
+	})
+	return nil
+}
+
+// addRevision stores the revision of a change already made, logging the failure.
+func (s *ServerImpl) addRevision(ctx context.Context, revision *Revision) {
+	revision.Time = s.now()
+	if e := TokenEntryFromContext(ctx); e != nil {
+		revision.Actor = e.Name
+	}
+	if err := s.revisions.Add(ctx, revision); err != nil {
+		slog.Error("add revision",
+			slog.String("name", qualifiedName(revision.Namespace, revision.Name)),
+			slog.Any("error", err))
+	}
+}
+
+// addAudit records the call with its error, logging the failure.
+func (s *ServerImpl) addAudit(ctx context.Context, entry *AuditEntry, err error) {
+	entry.Time = s.now()
+	entry.Remote = remoteAddrFromContext(ctx)
+	if e := TokenEntryFromContext(ctx); e != nil {
+		entry.Actor = e.Name
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := s.audit.Add(ctx, entry); err != nil {
+		slog.Error("add audit entry",
+			slog.String("op", string(entry.Op)),
+			slog.String("name", qualifiedName(entry.Namespace, entry.Name)),
+			slog.Any("error", err))
+	}
+}
+
+// Audit returns the calls changing the records of the namespaces the token may access.
+func (s *ServerImpl) Audit(ctx context.Context, r *AuditRequest) (*AuditResponse, error) {
+	if r.Name != "" {
+		if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+			return &AuditResponse{
+				Error: err.Error(),
+			}, err
+		}
+	}
+	entries, err := s.audit.Query(ctx, &AuditQuery{
+		Since:     r.Since,
+		Until:     r.Until,
+		Namespace: r.Namespace,
+		Name:      r.Name,
+	})
+	if err != nil {
+		return &AuditResponse{
+			Error: err.Error(),
+		}, err
+	}
+	es := []*AuditEntry{}
+	for _, e := range entries {
+		if authorizeNamespace(ctx, e.Namespace) == nil {
+			es = append(es, e)
+		}
+	}
+	return &AuditResponse{
+		Entries: es,
+	}, nil
+}
+
+func (s *ServerImpl) History(ctx context.Context, r *HistoryRequest) (*HistoryResponse, error) {
+	if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+		return &HistoryResponse{
+			Error: err.Error(),
+		}, err
+	}
+	revisions, err := s.revisions.List(ctx, r.Namespace, r.Name)
+	if err != nil {
+		return &HistoryResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &HistoryResponse{
+		Revisions: revisions,
+	}, nil
+}
+
+// Rollback restores the record to the revision, deleting it if the revision deleted it.
+func (s *ServerImpl) Rollback(ctx context.Context, r *RollbackRequest) (_ *RollbackResponse, err error) {
+	defer func() {
+		s.addAudit(ctx, &AuditEntry{Op: AuditOpRollback, Namespace: r.Namespace, Name: r.Name, Revision: r.Revision}, err)
+	}()
+	if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+		return &RollbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	revision, err := s.revisions.Get(ctx, r.Namespace, r.Name, r.Revision)
+	if err != nil {
+		return &RollbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if revision.New == nil {
+		if err := s.delete(ctx, r.Namespace, r.Name, Precondition{}, r.Revision); err != nil {
+			return &RollbackResponse{
+				Error: err.Error(),
+			}, err
+		}
+		return &RollbackResponse{}, nil
+	}
+	record := *revision.New
+	if err := s.put(ctx, &record, Precondition{}, r.Revision); err != nil {
+		return &RollbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &RollbackResponse{
+		Record: &record,
+	}, nil
+}
+
 func (s *ServerImpl) Redirect(ctx context.Context, r *RedirectRequest) (*RedirectResponse, error) {
-	record, err := s.db.Get(ctx, r.Name)
+	target, err := s.redirectTarget(ctx, r)
+	if err != nil {
+		return &RedirectResponse{
+			Error: err.Error(),
+		}, err
+	}
+	record, rest, params, err := s.lookup(ctx, target.namespace, target.name)
+	if errors.Is(err, ErrRecordNotFound) && target.inPath {
+		// the first segment may be a name in the default namespace, like a prefix record
+		if dr, drest, dparams, derr := s.lookup(ctx, DefaultNamespace, r.Name); !errors.Is(derr, ErrRecordNotFound) {
+			record, rest, params, err = dr, drest, dparams, derr
+		}
+	}
+	if err == nil {
+		err = s.checkActive(record)
+	}
+	if errors.Is(err, ErrRecordNotFound) {
+		return s.redirectFallback(ctx, target, r, err)
+	}
 	if err != nil {
 		return &RedirectResponse{
 			Error: err.Error(),
 		}, err
 	}
+	to := record.To
+	if params != nil {
+		if to, err = ExpandTemplate(to, params); err != nil {
+			return &RedirectResponse{
+				Error: err.Error(),
+			}, err
+		}
+	}
+	s.recordHit(record, r)
 	return &RedirectResponse{
-		To: record.To,
+		To:         location(to, record.QueryPolicy(), rest, r.Query),
+		StatusCode: record.RedirectStatusCode(),
+	}, nil
+}
+
+func (s *ServerImpl) recordHit(record *Record, r *RedirectRequest) {
+	if s.hits == nil {
+		return
+	}
+	s.hits.Record(NewHit(record.Namespace, record.Name, s.now(), r.Referrer, r.UserAgent))
+}
+
+// redirectTarget is the name the redirect looks up.
+type redirectTarget struct {
+	namespace string
+	name      string
+	// inPath is true if the namespace is the first segment of the path.
+	inPath bool
+}
+
+// path returns the path of the redirect by the name in the namespace of the target.
+func (t *redirectTarget) path(name string) string {
+	if t.inPath {
+		return qualifiedName(t.namespace, name)
+	}
+	return name
+}
+
+// redirectTarget selects the namespace of the redirect by the request, the domain of the host, or the path in order.
+func (s *ServerImpl) redirectTarget(ctx context.Context, r *RedirectRequest) (*redirectTarget, error) {
+	if r.Namespace != DefaultNamespace {
+		return &redirectTarget{
+			namespace: r.Namespace,
+			name:      r.Name,
+		}, nil
+	}
+	if r.Host != "" {
+		d, err := s.domains.Get(ctx, NormalizeHost(r.Host))
+		switch {
+		case err == nil:
+			return &redirectTarget{
+				namespace: d.Namespace,
+				name:      r.Name,
+			}, nil
+		case !errors.Is(err, ErrDomainNotFound):
+			return nil, err
+		}
+	}
+	return s.resolveNamespace(ctx, r.Name)
+}
+
+// resolveNamespace splits the path like <namespace>/<name> if the namespace has records.
+func (s *ServerImpl) resolveNamespace(ctx context.Context, path string) (*redirectTarget, error) {
+	inDefault := &redirectTarget{
+		namespace: DefaultNamespace,
+		name:      path,
+	}
+	namespace, name, ok := splitNamespace(path)
+	if !ok {
+		return inDefault, nil
+	}
+	index, err := s.db.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !index.HasNamespace(namespace) {
+		return inDefault, nil
+	}
+	return &redirectTarget{
+		namespace: namespace,
+		name:      name,
+		inPath:    true,
+	}, nil
+}
+
+func (s *ServerImpl) Stats(ctx context.Context, r *StatsRequest) (*StatsResponse, error) {
+	if err := authorizeNamespace(ctx, r.Namespace); err != nil {
+		return &StatsResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if _, err := s.db.Get(ctx, r.Namespace, r.Name); err != nil {
+		return &StatsResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if s.hits == nil {
+		return &StatsResponse{
+			Stats: &Stats{
+				Namespace: r.Namespace,
+				Name:      r.Name,
+			},
+		}, nil
+	}
+	stats, err := s.hits.Stats(ctx, r.Namespace, r.Name)
+	if err != nil {
+		return &StatsResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &StatsResponse{
+		Stats: stats,
+	}, nil
+}
+
+// redirectFallback responds to the redirect of the unknown target according to the fallback.
+func (s *ServerImpl) redirectFallback(ctx context.Context, target *redirectTarget, r *RedirectRequest, notFound error) (*RedirectResponse, error) {
+	fallback, err := s.fallback.Get(ctx)
+	if err != nil {
+		return &RedirectResponse{
+			Error: err.Error(),
+		}, err
+	}
+
+	switch fallback.Mode {
+	case FallbackRedirect:
+		return &RedirectResponse{
+			To:         fallback.To,
+			StatusCode: FallbackStatusCode,
+		}, nil
+	case FallbackRecord:
+		for _, name := range fallbackNames(target.name, fallback.Record) {
+			record, err := s.db.Get(ctx, target.namespace, name)
+			switch {
+			case errors.Is(err, ErrRecordNotFound):
+				continue
+			case err != nil:
+				return &RedirectResponse{
+					Error: err.Error(),
+				}, err
+			case !record.IsActive(s.now()):
+				continue
+			}
+			s.recordHit(record, r)
+			return &RedirectResponse{
+				To:         location(record.To, record.QueryPolicy(), "", r.Query),
+				StatusCode: record.RedirectStatusCode(),
+			}, nil
+		}
+	case FallbackSuggest:
+		index, err := s.db.Index(ctx)
+		if err != nil {
+			return &RedirectResponse{
+				Error: err.Error(),
+			}, err
+		}
+		suggestions := index.Suggest(target.namespace, target.name, fallback.MaxSuggestions())
+		for i, name := range suggestions {
+			suggestions[i] = target.path(name)
+		}
+		return &RedirectResponse{
+			Suggestions: suggestions,
+			Error:       notFound.Error(),
+		}, notFound
+	}
+	return &RedirectResponse{
+		Error: notFound.Error(),
+	}, notFound
+}
+
+func (s *ServerImpl) GetFallback(ctx context.Context, _ *GetFallbackRequest) (*GetFallbackResponse, error) {
+	fallback, err := s.fallback.Get(ctx)
+	if err != nil {
+		return &GetFallbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &GetFallbackResponse{
+		Fallback: fallback,
+	}, nil
+}
+
+func (s *ServerImpl) PutFallback(ctx context.Context, r *PutFallbackRequest) (*PutFallbackResponse, error) {
+	if r.Fallback == nil {
+		err := fmt.Errorf("%w, no fallback", ErrInvalidFallback)
+		return &PutFallbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := r.Fallback.Validate(); err != nil {
+		return &PutFallbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	// the fallback applies to all the namespaces
+	if err := authorizeAllNamespaces(ctx); err != nil {
+		return &PutFallbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := s.fallback.Put(ctx, r.Fallback); err != nil {
+		return &PutFallbackResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &PutFallbackResponse{
+		Fallback: r.Fallback,
+	}, nil
+}
+
+func (s *ServerImpl) ScanDomains(ctx context.Context, _ *ScanDomainsRequest) (*ScanDomainsResponse, error) {
+	domains, err := s.domains.Scan(ctx)
+	if err != nil {
+		return &ScanDomainsResponse{
+			Error: err.Error(),
+		}, err
+	}
+	var ds []*Domain
+	for _, d := range domains {
+		if authorizeNamespace(ctx, d.Namespace) == nil {
+			ds = append(ds, d)
+		}
+	}
+	if len(ds) == 0 {
+		return &ScanDomainsResponse{
+			Error: ErrDomainNotFound.Error(),
+		}, ErrDomainNotFound
+	}
+	return &ScanDomainsResponse{
+		Domains: ds,
 	}, nil
 }
+
+func (s *ServerImpl) GetDomain(ctx context.Context, r *GetDomainRequest) (*GetDomainResponse, error) {
+	domain, err := s.domains.Get(ctx, NormalizeHost(r.Host))
+	if err == nil {
+		err = authorizeNamespace(ctx, domain.Namespace)
+	}
+	if err != nil {
+		return &GetDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &GetDomainResponse{
+		Domain: domain,
+	}, nil
+}
+
+func (s *ServerImpl) PutDomain(ctx context.Context, r *PutDomainRequest) (*PutDomainResponse, error) {
+	if r.Domain == nil {
+		err := fmt.Errorf("%w, no domain", ErrInvalidDomain)
+		return &PutDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := r.Domain.Validate(); err != nil {
+		return &PutDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	// a host is shared by the namespaces
+	if err := authorizeAllNamespaces(ctx); err != nil {
+		return &PutDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := s.domains.Put(ctx, r.Domain); err != nil {
+		return &PutDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return &PutDomainResponse{
+		Domain: r.Domain,
+	}, nil
+}
+
+func (s *ServerImpl) DeleteDomain(ctx context.Context, r *DeleteDomainRequest) (*DeleteDomainResponse, error) {
+	if err := authorizeAllNamespaces(ctx); err != nil {
+		return &DeleteDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	if err := s.domains.Delete(ctx, NormalizeHost(r.Host)); err != nil {
+		return &DeleteDomainResponse{
+			Error: err.Error(),
+		}, err
+	}
+	return nil, nil
+}
+
+func (s *ServerImpl) checkActive(record *Record) error {
+	var (
+		now  = s.now()
+		name = qualifiedName(record.Namespace, record.Name)
+	)
+	switch {
+	case record.IsPending(now):
+		return fmt.Errorf("%w, %s is not active yet", ErrRecordNotFound, name)
+	case record.IsExpired(now) && s.goneOnExpired:
+		return fmt.Errorf("%w, %s", ErrRecordGone, name)
+	case record.IsExpired(now):
+		return fmt.Errorf("%w, %s is expired", ErrRecordNotFound, name)
+	default:
+		return nil
+	}
+}
+
+// lookup returns the record, or the pattern record and its parameters, or the prefix record and the rest.
+func (s *ServerImpl) lookup(ctx context.Context, namespace, name string) (*Record, string, map[string]string, error) {
+	record, err := s.db.Get(ctx, namespace, name)
+	if !errors.Is(err, ErrRecordNotFound) {
+		return record, "", nil, err
+	}
+
+	index, err := s.db.Index(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if record, params, ok := index.MatchPattern(namespace, name); ok {
+		return record, "", params, nil
+	}
+	if record, rest, ok := index.MatchPrefix(namespace, name); ok {
+		return record, rest, nil, nil
+	}
+	return nil, "", nil, fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
+}
+
+// checkPatternConflict returns an error if the pattern record overlaps another. Requires writeMux.
+func (s *ServerImpl) checkPatternConflict(ctx context.Context, record *Record) error {
+	if !IsPattern(record.Name) {
+		return nil
+	}
+	p, err := ParsePattern(record.Name)
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidRecord, err)
+	}
+	records, err := s.db.Scan(ctx)
+	switch {
+	case errors.Is(err, ErrRecordNotFound):
+		return nil
+	case err != nil:
+		return err
+	}
+	return patternConflict(p, record, records)
+}
+
+// patternConflict returns an error if the pattern p of the record overlaps another pattern record of the records in its namespace.
+func patternConflict(p *Pattern, record *Record, records []*Record) error {
+	for _, r := range records {
+		if r.Namespace != record.Namespace || r.Name == record.Name || !IsPattern(r.Name) {
+			continue
+		}
+		other, err := ParsePattern(r.Name)
+		if err != nil {
+			continue
+		}
+		if p.Overlaps(other) {
+			return fmt.Errorf("%w, pattern %s overlaps %s", ErrInvalidRecord, record.Name, r.Name)
+		}
+	}
+	return nil
+}
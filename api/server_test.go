@@ -0,0 +1,292 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func newTestServer(t *testing.T, records ...*api.Record) *api.ServerImpl {
+	t.Helper()
+	_, dbFile := newDatabaseFile(t)
+	server := api.NewServerImpl(api.NewDatabaseImpl(dbFile))
+	for _, r := range records {
+		if _, err := server.Put(context.Background(), &api.PutRequest{
+			Record: r,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return server
+}
+
+func TestServerImplRedirect(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "exact", To: "https://example.com/exact"},
+		&api.Record{Name: "docs", To: "https://example.com/docs/", Match: api.MatchPrefix},
+		&api.Record{Name: "docs/api", To: "https://api.example.com/v1?lang=en", Match: api.MatchPrefix},
+		&api.Record{Name: "docs/api/old", To: "https://example.com/old"},
+		&api.Record{Name: "gh/{org}/{repo}", To: "https://github.com/{org}/{repo}"},
+		&api.Record{Name: "gh/{org}/{repo}/issues", To: "https://github.com/{org}/{repo}/issues?q=is%3Aopen"},
+		&api.Record{Name: "gh/berquerant/exact", To: "https://example.com/exact"},
+		&api.Record{Name: "s/{term}", To: "https://example.com/search?q={term}"},
+	)
+
+	for _, tc := range []struct {
+		title string
+		name  string
+		want  string
+	}{
+		{
+			title: "exact",
+			name:  "exact",
+			want:  "https://example.com/exact",
+		},
+		{
+			title: "exact record does not match names under it",
+			name:  "exact/x",
+		},
+		{
+			title: "prefix record itself",
+			name:  "docs",
+			want:  "https://example.com/docs/",
+		},
+		{
+			title: "prefix",
+			name:  "docs/getting-started",
+			want:  "https://example.com/docs/getting-started",
+		},
+		{
+			title: "trailing slash",
+			name:  "docs/guide/",
+			want:  "https://example.com/docs/guide/",
+		},
+		{
+			title: "escape",
+			name:  "docs/a b",
+			want:  "https://example.com/docs/a%20b",
+		},
+		{
+			title: "longest prefix wins",
+			name:  "docs/api/users",
+			want:  "https://api.example.com/v1/users?lang=en",
+		},
+		{
+			title: "exact record under prefix",
+			name:  "docs/api/old",
+			want:  "https://example.com/old",
+		},
+		{
+			title: "prefix skips exact record",
+			name:  "docs/api/old/x",
+			want:  "https://api.example.com/v1/old/x?lang=en",
+		},
+		{
+			title: "not a path prefix",
+			name:  "docsx",
+		},
+		{
+			title: "pattern",
+			name:  "gh/berquerant/experimental-terraform-redirect-store",
+			want:  "https://github.com/berquerant/experimental-terraform-redirect-store",
+		},
+		{
+			title: "pattern with literal",
+			name:  "gh/berquerant/repo/issues",
+			want:  "https://github.com/berquerant/repo/issues?q=is%3Aopen",
+		},
+		{
+			title: "exact record wins over pattern",
+			name:  "gh/berquerant/exact",
+			want:  "https://example.com/exact",
+		},
+		{
+			title: "pattern in query cannot add parameters",
+			name:  "s/a&admin=1",
+			want:  "https://example.com/search?q=a%26admin%3D1",
+		},
+		{
+			title: "pattern does not match fewer segments",
+			name:  "gh/berquerant",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			got, err := server.Redirect(context.Background(), &api.RedirectRequest{
+				Name: tc.name,
+			})
+			if tc.want == "" {
+				if !errors.Is(err, api.ErrRecordNotFound) {
+					t.Fatalf("want ErrRecordNotFound, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.To != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got.To)
+			}
+		})
+	}
+}
+
+// scanCountingDatabase counts the scans of the database.
+type scanCountingDatabase struct {
+	api.Database
+	scans atomic.Int64
+}
+
+func (db *scanCountingDatabase) Scan(ctx context.Context) ([]*api.Record, error) {
+	db.scans.Add(1)
+	return db.Database.Scan(ctx)
+}
+
+func TestServerImplRedirectWithoutScan(t *testing.T) {
+	_, dbFile := newDatabaseFile(t)
+	db := &scanCountingDatabase{Database: api.NewDatabaseImpl(dbFile)}
+	server := api.NewServerImpl(db)
+	ctx := context.Background()
+	for _, r := range []*api.Record{
+		{Namespace: "team", Name: "docs", To: "https://example.com/docs/", Match: api.MatchPrefix},
+		{Namespace: "team", Name: "gh/{org}/{repo}", To: "https://github.com/{org}/{repo}"},
+	} {
+		if _, err := server.Put(ctx, &api.PutRequest{Record: r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db.scans.Store(0)
+	for _, r := range []*api.RedirectRequest{
+		{Namespace: "team", Name: "gh/a/b"},
+		{Namespace: "team", Name: "docs/x"},
+		{Name: "team/gh/a/b"},
+		{Name: "team/missing/x"},
+		{Name: "missing/x"},
+	} {
+		if _, err := server.Redirect(ctx, r); err != nil && !errors.Is(err, api.ErrRecordNotFound) {
+			t.Fatal(err)
+		}
+	}
+	if n := db.scans.Load(); n != 0 {
+		t.Errorf("want no scans, got %d", n)
+	}
+}
+
+func TestServerImplPutPattern(t *testing.T) {
+	server := newTestServer(t,
+		&api.Record{Name: "gh/{org}/{repo}", To: "https://github.com/{org}/{repo}"},
+	)
+
+	for _, tc := range []struct {
+		title  string
+		record *api.Record
+		err    bool
+	}{
+		{
+			title:  "update itself",
+			record: &api.Record{Name: "gh/{org}/{repo}", To: "https://github.com/{repo}"},
+		},
+		{
+			title:  "not overlapping",
+			record: &api.Record{Name: "gh/{org}", To: "https://github.com/{org}"},
+		},
+		{
+			title:  "overlapping",
+			record: &api.Record{Name: "gh/berquerant/{name}", To: "https://github.com/berquerant/{name}"},
+			err:    true,
+		},
+		{
+			title:  "unknown parameter",
+			record: &api.Record{Name: "gl/{org}", To: "https://gitlab.com/{group}"},
+			err:    true,
+		},
+		{
+			title:  "prefix pattern",
+			record: &api.Record{Name: "gl/{org}", To: "https://gitlab.com/{org}", Match: api.MatchPrefix},
+			err:    true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := server.Put(context.Background(), &api.PutRequest{
+				Record: tc.record,
+			})
+			if tc.err {
+				if !errors.Is(err, api.ErrInvalidRecord) {
+					t.Fatalf("want ErrInvalidRecord, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestServerImplPutPatternConcurrently(t *testing.T) {
+	server := newTestServer(t)
+	var (
+		wg  sync.WaitGroup
+		put atomic.Int64
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// all the patterns overlap each other
+			name := fmt.Sprintf("gh/{org%d}/{repo}", i)
+			if _, err := server.Put(context.Background(), &api.PutRequest{
+				Record: &api.Record{Name: name, To: "https://github.com/{repo}"},
+			}); err == nil {
+				put.Add(1)
+			} else if !errors.Is(err, api.ErrInvalidRecord) {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := put.Load(); n != 1 {
+		t.Errorf("want 1 pattern put, got %d", n)
+	}
+}
+
+func TestServerImplRedirectWindow(t *testing.T) {
+	var (
+		past   = time.Now().Add(-time.Hour)
+		future = time.Now().Add(time.Hour)
+		server = newTestServer(t,
+			&api.Record{Name: "active", To: "https://example.com/", NotBefore: &past, ExpiresAt: &future},
+			&api.Record{Name: "pending", To: "https://example.com/", NotBefore: &future},
+			&api.Record{Name: "expired", To: "https://example.com/", ExpiresAt: &past},
+		)
+		redirect = func(name string) error {
+			_, err := server.Redirect(context.Background(), &api.RedirectRequest{
+				Name: name,
+			})
+			return err
+		}
+	)
+
+	if err := redirect("active"); err != nil {
+		t.Errorf("active: %v", err)
+	}
+	if err := redirect("pending"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Errorf("pending: want ErrRecordNotFound, got %v", err)
+	}
+	if err := redirect("expired"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Errorf("expired: want ErrRecordNotFound, got %v", err)
+	}
+	server.SetGoneOnExpired(true)
+	if err := redirect("expired"); !errors.Is(err, api.ErrRecordGone) {
+		t.Errorf("expired: want ErrRecordGone, got %v", err)
+	}
+	if err := redirect("pending"); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Errorf("pending with gone: want ErrRecordNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	_ Database = &SQLDatabase{}
+)
+
+// SQLDatabase is a Database on top of SQLite, not to be written by others.
+type SQLDatabase struct {
+	db    *sql.DB
+	index lazyRecordIndex
+}
+
+// NewSQLDatabase opens the SQLite database and migrates its schema to the latest version.
+func NewSQLDatabase(ctx context.Context, dsn string) (*SQLDatabase, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w, open %v", ErrConnectDatabase, err)
+	}
+	// SQLite allows a single writer
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w, ping %v", ErrConnectDatabase, err)
+	}
+	if err := Migrate(ctx, db, sqliteMigrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLDatabase{
+		db: db,
+	}, nil
+}
+
+func (db *SQLDatabase) Close() error {
+	return db.db.Close()
+}
+
+const sqlRecordColumns = `namespace, name, to_url, status_code, match_mode, query_policy, not_before, expires_at, version`
+
+type sqlScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSQLRecord(row sqlScanner) (*Record, error) {
+	var (
+		r                    Record
+		notBefore, expiresAt sql.NullString
+	)
+	if err := row.Scan(&r.Namespace, &r.Name, &r.To, &r.StatusCode, &r.Match, &r.Query, &notBefore, &expiresAt, &r.Version); err != nil {
+		return nil, err
+	}
+	var err error
+	if r.NotBefore, err = parseSQLTime(notBefore); err != nil {
+		return nil, err
+	}
+	if r.ExpiresAt, err = parseSQLTime(expiresAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func parseSQLTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func formatSQLTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{
+		String: t.Format(time.RFC3339Nano),
+		Valid:  true,
+	}
+}
+
+func (db *SQLDatabase) Scan(ctx context.Context) ([]*Record, error) {
+	rows, err := db.db.QueryContext(ctx, `SELECT `+sqlRecordColumns+` FROM records ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("%w, select %v", ErrReadDatabase, err)
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		r, err := scanSQLRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w, scan %v", ErrReadDatabase, err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w, rows %v", ErrReadDatabase, err)
+	}
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return records, nil
+}
+
+func (db *SQLDatabase) Get(ctx context.Context, namespace, name string) (*Record, error) {
+	r, err := scanSQLRecord(db.db.QueryRowContext(ctx, `SELECT `+sqlRecordColumns+` FROM records WHERE namespace = ? AND name = ?`, namespace, name))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
+	case err != nil:
+		return nil, fmt.Errorf("%w, select %v", ErrReadDatabase, err)
+	default:
+		return r, nil
+	}
+}
+
+// sqlExecer is *sql.DB or *sql.Tx.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Index returns the index of the records, rebuilt by a scan after the writes.
+func (db *SQLDatabase) Index(ctx context.Context) (*RecordIndex, error) {
+	return db.index.get(ctx, db.Scan)
+}
+
+func (db *SQLDatabase) Put(ctx context.Context, record *Record) error {
+	defer db.index.invalidate()
+	return sqlPut(ctx, db.db, record)
+}
+
+func sqlPut(ctx context.Context, exec sqlExecer, record *Record) error {
+	if _, err := exec.ExecContext(ctx,
+		`INSERT INTO records (`+sqlRecordColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (namespace, name) DO UPDATE SET
+  to_url = excluded.to_url,
+  status_code = excluded.status_code,
+  match_mode = excluded.match_mode,
+  query_policy = excluded.query_policy,
+  not_before = excluded.not_before,
+  expires_at = excluded.expires_at,
+  version = excluded.version`,
+		record.Namespace, record.Name, record.To, record.StatusCode, record.Match, record.Query,
+		formatSQLTime(record.NotBefore), formatSQLTime(record.ExpiresAt), record.Version,
+	); err != nil {
+		return fmt.Errorf("%w, upsert %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
+
+func (db *SQLDatabase) Delete(ctx context.Context, namespace, name string) error {
+	defer db.index.invalidate()
+	return sqlDelete(ctx, db.db, namespace, name)
+}
+
+func sqlDelete(ctx context.Context, exec sqlExecer, namespace, name string) error {
+	result, err := exec.ExecContext(ctx, `DELETE FROM records WHERE namespace = ? AND name = ?`, namespace, name)
+	if err != nil {
+		return fmt.Errorf("%w, delete %v", ErrWriteDatabase, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w, delete %v", ErrWriteDatabase, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w, %s", ErrRecordNotFound, qualifiedName(namespace, name))
+	}
+	return nil
+}
+
+func (db *SQLDatabase) Batch(ctx context.Context, ops []*BatchOperation) error {
+	defer db.index.invalidate()
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w, begin %v", ErrWriteDatabase, err)
+	}
+	defer tx.Rollback()
+	for _, o := range ops {
+		if o.Op == BatchOpPut {
+			err = sqlPut(ctx, tx, o.Record)
+		} else {
+			err = sqlDelete(ctx, tx, o.Namespace, o.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w, commit %v", ErrWriteDatabase, err)
+	}
+	return nil
+}
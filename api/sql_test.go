@@ -0,0 +1,23 @@
+package api_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+	"experimental-terraform-redirect-store/api/databasetest"
+)
+
+func TestSQLDatabase(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) api.Database {
+		db, err := api.NewSQLDatabase(context.Background(), filepath.Join(t.TempDir(), "api.sqlite"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+		})
+		return db
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// SweeperActor is the actor of the revisions and the audit entries of the sweeps.
+const SweeperActor = "sweeper"
+
+// Sweeper periodically deletes expired records through the server.
+type Sweeper struct {
+	server   *ServerImpl
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewSweeper(server *ServerImpl, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		server:   server,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run sweeps every interval until ctx is canceled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.Sweep(ctx)
+			if err != nil {
+				slog.Error("sweep", slog.Any("error", err))
+				continue
+			}
+			if n > 0 {
+				slog.Info("sweep", slog.Int("deleted", n))
+			}
+		}
+	}
+}
+
+// Sweep deletes the expired records unchanged since the scan and returns the number of them.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	ctx = context.WithValue(ctx, tokenEntryKey{}, &TokenEntry{Name: SweeperActor})
+	records, err := s.server.db.Scan(ctx)
+	switch {
+	case errors.Is(err, ErrRecordNotFound):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+
+	var (
+		now = s.now()
+		n   int
+	)
+	for _, r := range records {
+		if !r.IsExpired(now) {
+			continue
+		}
+		err := s.server.delete(ctx, r.Namespace, r.Name, Precondition{IfVersion: r.Version}, 0)
+		if errors.Is(err, ErrRecordNotFound) || errors.Is(err, ErrConflict) {
+			// deleted or changed by others
+			continue
+		}
+		s.server.addAudit(ctx, &AuditEntry{Op: AuditOpDelete, Namespace: r.Namespace, Name: r.Name}, err)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
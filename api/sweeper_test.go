@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestSweeper(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		past   = time.Now().Add(-time.Hour)
+		future = time.Now().Add(time.Hour)
+		server = newTestServer(t,
+			&api.Record{Name: "expired", To: "https://example.com/", ExpiresAt: &past},
+			&api.Record{Name: "active", To: "https://example.com/", ExpiresAt: &future},
+			&api.Record{Name: "unlimited", To: "https://example.com/"},
+		)
+	)
+
+	n, err := api.NewSweeper(server, time.Minute).Sweep(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("want 1 deleted, got %d", n)
+	}
+	if _, err := server.Get(ctx, &api.GetRequest{Name: "expired"}); !errors.Is(err, api.ErrRecordNotFound) {
+		t.Errorf("want expired deleted, got %v", err)
+	}
+	records, err := server.Scan(ctx, &api.ScanRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records.Records) != 2 {
+		t.Errorf("want 2 records, got %d", len(records.Records))
+	}
+
+	history, err := server.History(ctx, &api.HistoryRequest{Name: "expired"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history.Revisions[len(history.Revisions)-1]; got.Op != api.RevisionOpDelete || got.Actor != api.SweeperActor {
+		t.Errorf("want the delete revision of the sweeper, got %+v", got)
+	}
+	audit, err := server.Audit(ctx, &api.AuditRequest{Name: "expired"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := audit.Entries[len(audit.Entries)-1]; got.Op != api.AuditOpDelete || got.Actor != api.SweeperActor || got.Error != "" {
+		t.Errorf("want the delete entry of the sweeper, got %+v", got)
+	}
+}
+
+// staleScanDatabase scans the records of a snapshot, as if they changed after the scan.
+type staleScanDatabase struct {
+	api.Database
+	records []*api.Record
+}
+
+func (db *staleScanDatabase) Scan(_ context.Context) ([]*api.Record, error) {
+	return db.records, nil
+}
+
+func TestSweeperChangedRecord(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		past   = time.Now().Add(-time.Hour)
+		_, f   = newDatabaseFile(t)
+		db     = &staleScanDatabase{Database: api.NewDatabaseImpl(f)}
+		server = api.NewServerImpl(db)
+	)
+	if _, err := server.Put(ctx, &api.PutRequest{Record: &api.Record{Name: "renewed", To: "https://example.com/", ExpiresAt: &past}}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := db.Database.Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.records = records
+	if _, err := server.Put(ctx, &api.PutRequest{Record: &api.Record{Name: "renewed", To: "https://example.com/"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := api.NewSweeper(server, time.Minute).Sweep(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("want none deleted, got %d", n)
+	}
+	if _, err := server.Get(ctx, &api.GetRequest{Name: "renewed"}); err != nil {
+		t.Errorf("want renewed kept, got %v", err)
+	}
+}
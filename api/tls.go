@@ -0,0 +1,173 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidTLS = errors.New("InvalidTLS")
+)
+
+// DefaultCertCheckInterval is how often CertReloader looks for the renewed certificate.
+const DefaultCertCheckInterval = 10 * time.Second
+
+// CertReloader serves the certificate from the files, reloading it when the files change.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	now      func() time.Time
+
+	mux       sync.Mutex
+	cert      *tls.Certificate
+	stats     []os.FileInfo
+	checkedAt time.Time
+}
+
+// NewCertReloader loads the PEM encoded certificate and key.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: DefaultCertCheckInterval,
+		now:      time.Now,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetCheckInterval changes how often the files are checked for changes.
+func (r *CertReloader) SetCheckInterval(interval time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.interval = interval
+}
+
+func (r *CertReloader) statFiles() []os.FileInfo {
+	var stats []os.FileInfo
+	for _, f := range []string{r.certFile, r.keyFile} {
+		s, err := os.Stat(f)
+		if err != nil {
+			return nil
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+func (r *CertReloader) reload() error {
+	stats := r.statFiles()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("%w, load certificate %v", ErrInvalidTLS, err)
+	}
+	r.cert = &cert
+	r.stats = stats
+	r.checkedAt = r.now()
+	return nil
+}
+
+// GetCertificate is tls.Config.GetCertificate, keeping the current certificate if the renewed one is broken.
+func (r *CertReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if now := r.now(); now.Sub(r.checkedAt) >= r.interval {
+		r.checkedAt = now
+		if !sameFileStats(r.stats, r.statFiles()) {
+			if err := r.reload(); err != nil {
+				slog.Error("reload certificate", slog.String("cert", r.certFile), slog.Any("error", err))
+			} else {
+				slog.Info("reload certificate", slog.String("cert", r.certFile))
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w, read CA bundle %v", ErrInvalidTLS, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%w, no certificates in %s", ErrInvalidTLS, caFile)
+	}
+	return pool, nil
+}
+
+// NewServerTLSConfig returns the TLS config serving the certificate, verifying optional client certificates.
+func NewServerTLSConfig(reloader *CertReloader, clientCAFile string) (*tls.Config, error) {
+	c := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if clientCAFile == "" {
+		return c, nil
+	}
+	pool, err := loadCertPool(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	c.ClientCAs = pool
+	c.ClientAuth = tls.VerifyClientCertIfGiven
+	return c, nil
+}
+
+// RequireClientCert rejects the requests without a verified client certificate.
+func RequireClientCert(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			slog.Info("auth", slog.String("url", r.URL.String()), slog.String("error", "no client certificate"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// NewClientTLSConfig returns the TLS config of the clients, nil if all the files are empty.
+func NewClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	c := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		c.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w, load client certificate %v", ErrInvalidTLS, err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+// NewTransport returns http.DefaultTransport with the TLS config.
+func NewTransport(tlsConfig *tls.Config) http.RoundTripper {
+	if tlsConfig == nil {
+		return http.DefaultTransport
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	return t
+}
@@ -0,0 +1,208 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+// testCA issues certificates for the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue writes the certificate and the key signed by the CA to the dir and returns their paths.
+func (ca *testCA) issue(t *testing.T, dir, name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		certFile = filepath.Join(dir, name+".crt")
+		keyFile  = filepath.Join(dir, name+".key")
+	)
+	writeTestFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeTestFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certFile, keyFile
+}
+
+func writeTestFile(t *testing.T, name string, b []byte) {
+	t.Helper()
+	// write and rename as the certificate renewal tools do
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		ca     = newTestCA(t)
+		caFile = filepath.Join(dir, "ca.crt")
+	)
+	writeTestFile(t, caFile, ca.pem)
+	serverCert, serverKey := ca.issue(t, dir, "server", 10, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "client", 20, x509.ExtKeyUsageClientAuth)
+
+	reloader, err := api.NewCertReloader(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader.SetCheckInterval(0)
+	serverTLS, err := api.NewServerTLSConfig(reloader, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newTestServer(t, &api.Record{Name: "docs", To: "https://example.com/docs"})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/get", api.RequireClientCert(api.API(server.Get)))
+	mux.HandleFunc("/c/", api.RedirectHandler(server, "/c/"))
+	// StartTLS would serve the certificate of httptest instead of the reloader
+	ts := httptest.NewUnstartedServer(mux)
+	ts.Listener = tls.NewListener(ts.Listener, serverTLS)
+	ts.Start()
+	defer ts.Close()
+	url := "https://" + ts.Listener.Addr().String()
+
+	newHTTPClient := func(t *testing.T, certFile, keyFile string) *http.Client {
+		t.Helper()
+		c, err := api.NewClientTLSConfig(caFile, certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &http.Client{
+			Transport: api.NewTransport(c),
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("management requires client certificate", func(t *testing.T) {
+		client := api.NewClientImpl(url, newHTTPClient(t, "", ""))
+		if _, err := client.Get(ctx, "docs"); !errors.Is(err, api.ErrForbidden) {
+			t.Errorf("want ErrForbidden, got %v", err)
+		}
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		client := api.NewClientImpl(url, newHTTPClient(t, clientCert, clientKey))
+		if _, err := client.Get(ctx, "docs"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("redirect is public", func(t *testing.T) {
+		resp, err := newHTTPClient(t, "", "").Get(url + "/c/docs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMovedPermanently {
+			t.Errorf("want %d, got %d", http.StatusMovedPermanently, resp.StatusCode)
+		}
+	})
+
+	t.Run("reload certificate", func(t *testing.T) {
+		ca.issue(t, dir, "server", 11, x509.ExtKeyUsageServerAuth)
+		conn, err := tls.Dial("tcp", ts.Listener.Addr().String(), &tls.Config{
+			RootCAs: func() *x509.CertPool {
+				p := x509.NewCertPool()
+				p.AddCert(ca.cert)
+				return p
+			}(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if got := conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(); got != 11 {
+			t.Errorf("want the renewed certificate 11, got %d", got)
+		}
+	})
+}
+
+func TestNewClientTLSConfig(t *testing.T) {
+	c, err := api.NewClientTLSConfig("", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != nil {
+		t.Errorf("want nil, got %+v", c)
+	}
+	if _, err := api.NewClientTLSConfig(filepath.Join(t.TempDir(), "missing"), "", ""); !errors.Is(err, api.ErrInvalidTLS) {
+		t.Errorf("want ErrInvalidTLS, got %v", err)
+	}
+}
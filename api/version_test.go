@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"experimental-terraform-redirect-store/api"
+)
+
+func TestServerImplVersion(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	put := func(to string, precondition api.Precondition) (*api.Record, error) {
+		res, err := server.Put(ctx, &api.PutRequest{
+			Record:       &api.Record{Name: "x", To: to},
+			Precondition: precondition,
+		})
+		return res.Record, err
+	}
+
+	got, err := put("https://example.com/1", api.Precondition{IfAbsent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 1 {
+		t.Errorf("want version 1, got %d", got.Version)
+	}
+	if _, err := put("https://example.com/other", api.Precondition{IfAbsent: true}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("if_absent: want ErrConflict, got %v", err)
+	}
+	if _, err := put("https://example.com/other", api.Precondition{IfVersion: 2}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("stale if_version: want ErrConflict, got %v", err)
+	}
+	got, err = put("https://example.com/2", api.Precondition{IfVersion: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 2 {
+		t.Errorf("want version 2, got %d", got.Version)
+	}
+	got, err = put("https://example.com/3", api.Precondition{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 {
+		t.Errorf("unconditional: want version 3, got %d", got.Version)
+	}
+	if _, err := put("https://example.com/4", api.Precondition{IfVersion: 3, IfAbsent: true}); !errors.Is(err, api.ErrInvalidRecord) {
+		t.Errorf("both: want ErrInvalidRecord, got %v", err)
+	}
+
+	stored, err := server.Get(ctx, &api.GetRequest{Name: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Record.To != "https://example.com/3" || stored.Record.Version != 3 {
+		t.Errorf("want https://example.com/3 at version 3, got %+v", stored.Record)
+	}
+
+	if _, err := server.Delete(ctx, &api.DeleteRequest{Name: "x", Precondition: api.Precondition{IfVersion: 2}}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("delete stale if_version: want ErrConflict, got %v", err)
+	}
+	if _, err := server.Delete(ctx, &api.DeleteRequest{Name: "x", Precondition: api.Precondition{IfVersion: 3}}); err != nil {
+		t.Errorf("delete: want no error, got %v", err)
+	}
+	if _, err := put("https://example.com/5", api.Precondition{IfVersion: 3}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("if_version of deleted: want ErrConflict, got %v", err)
+	}
+}
+
+func TestClientImplConflict(t *testing.T) {
+	server := newTestServer(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/put", api.API(server.Put))
+	mux.HandleFunc("/delete", api.API(server.Delete))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := api.NewClientImpl(ts.URL, http.DefaultClient)
+	got, err := client.PutIf(ctx, &api.Record{Name: "x", To: "https://example.com"}, api.Precondition{IfAbsent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 1 {
+		t.Errorf("want version 1, got %d", got.Version)
+	}
+	if _, err := client.PutIf(ctx, &api.Record{Name: "x", To: "https://example.com"}, api.Precondition{IfAbsent: true}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("want ErrConflict, got %v", err)
+	}
+	if err := client.DeleteIf(ctx, "x", api.Precondition{IfVersion: 5}); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("want ErrConflict, got %v", err)
+	}
+}
@@ -0,0 +1,4 @@
+# Look up a record by name.
+data "redirect-store_record" "example" {
+  name = "framework"
+}
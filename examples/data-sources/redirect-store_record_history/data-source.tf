@@ -0,0 +1,4 @@
+# List the changes of a record.
+data "redirect-store_record_history" "example" {
+  name = "framework"
+}
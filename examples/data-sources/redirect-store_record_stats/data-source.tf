@@ -0,0 +1,4 @@
+# Count the redirects by a record.
+data "redirect-store_record_stats" "example" {
+  name = "framework"
+}
@@ -1,2 +1,8 @@
-# List all records.
+# List all records in the namespace of the provider.
 data "redirect-store_records" "example" {}
+
+# List the records under docs/ redirecting to example.com.
+data "redirect-store_records" "docs" {
+  name_prefix = "docs/"
+  to_contains = "example.com"
+}
@@ -0,0 +1,2 @@
+# Import the domain by the host.
+terraform import redirect-store_domain.example go.example.com
@@ -0,0 +1,5 @@
+# Serve the records of the namespace team on go.example.com.
+resource "redirect-store_domain" "example" {
+  host      = "go.example.com"
+  namespace = "team"
+}
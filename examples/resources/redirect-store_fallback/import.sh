@@ -0,0 +1,2 @@
+# There is only one fallback per server.
+terraform import redirect-store_fallback.example fallback
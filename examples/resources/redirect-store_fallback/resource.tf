@@ -0,0 +1,4 @@
+# List the closest names for unknown names.
+resource "redirect-store_fallback" "example" {
+  mode = "suggest"
+}
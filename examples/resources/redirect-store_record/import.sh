@@ -1 +1,3 @@
 terraform import redirect-store_record.example 123
+# Import the record in the namespace.
+terraform import redirect-store_record.example team:123
@@ -0,0 +1,245 @@
+package provider
+
+import (
+	"context"
+	"experimental-terraform-redirect-store/api"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &domainResource{}
+	_ resource.ResourceWithConfigure      = &domainResource{}
+	_ resource.ResourceWithImportState    = &domainResource{}
+	_ resource.ResourceWithValidateConfig = &domainResource{}
+)
+
+// NewDomainResource is a helper function to simplify the provider implementation.
+func NewDomainResource() resource.Resource {
+	return &domainResource{}
+}
+
+// domainResource is the resource implementation.
+type domainResource struct {
+	client api.Client
+}
+
+type domainResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	Host        types.String `tfsdk:"host"`
+	Namespace   types.String `tfsdk:"namespace"`
+	LastUpdated types.String `tfsdk:"last_updated"`
+}
+
+// domain builds the domain from the model.
+// The namespace is that of the provider if not set.
+func (m domainResourceModel) domain(client api.Client) *api.Domain {
+	namespace := client.Namespace()
+	if !m.Namespace.IsNull() && !m.Namespace.IsUnknown() {
+		namespace = m.Namespace.ValueString()
+	}
+	return &api.Domain{
+		Host:      m.Host.ValueString(),
+		Namespace: namespace,
+	}
+}
+
+// Metadata returns the resource type name.
+func (r *domainResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_domain"
+}
+
+// Schema defines the schema for the resource.
+func (r *domainResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a domain, the host of the redirects selecting the namespace of the records.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Placeholder identifier attribute.",
+				Computed:    true,
+			},
+			"host": schema.StringAttribute{
+				Description: "Host of the redirects in lower case without port, e.g. `go.example.com`. Changing it replaces the domain.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"namespace": schema.StringAttribute{
+				Description: "Namespace of the records the redirects of the host look up. Defaults to the namespace of the provider.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.String{
+					namespaceValidator{},
+				},
+			},
+			"last_updated": schema.StringAttribute{
+				Description: "Timestamp of the last Terraform update of the domain.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// ValidateConfig validates the host.
+func (r *domainResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config domainResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Host.IsUnknown() || config.Host.IsNull() {
+		return
+	}
+	if err := (&api.Domain{Host: config.Host.ValueString()}).Validate(); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("host"),
+			"Invalid Domain",
+			err.Error(),
+		)
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *domainResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from plan
+	var plan domainResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	domain, err := r.client.PutDomain(ctx, plan.domain(r.client))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating domain",
+			"Could not create domain, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(domain.Host)
+	plan.Namespace = types.StringValue(domain.Namespace)
+	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *domainResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state domainResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	domain, err := r.client.GetDomain(ctx, state.Host.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading RedirectStore Domain",
+			"Could not read RedirectStore domain host "+state.Host.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	state.ID = types.StringValue(domain.Host)
+	state.Host = types.StringValue(domain.Host)
+	state.Namespace = types.StringValue(domain.Namespace)
+
+	// Set refreshed state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *domainResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from plan
+	var plan domainResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	domain, err := r.client.PutDomain(ctx, plan.domain(r.client))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error updating domain",
+			"Could not update domain, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(domain.Host)
+	plan.Namespace = types.StringValue(domain.Namespace)
+	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *domainResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state domainResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.DeleteDomain(ctx, state.Host.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting domain",
+			"Could not delete domain, unexpected error: "+err.Error(),
+		)
+		return
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *domainResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(api.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected api.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *domainResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("host"), req, resp)
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDomainResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create domain
+			{
+				Config: providerConfig + `resource "redirect-store_domain" "test" {
+  host = "go.example.com"
+  namespace = "team"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_domain.test", "id", "go.example.com"),
+					resource.TestCheckResourceAttr("redirect-store_domain.test", "namespace", "team"),
+				),
+			},
+			// Import state
+			{
+				ResourceName:            "redirect-store_domain.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"last_updated"},
+			},
+			// Update namespace
+			{
+				Config: providerConfig + `resource "redirect-store_domain" "test" {
+  host = "go.example.com"
+  namespace = "ops"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_domain.test", "namespace", "ops"),
+				),
+			},
+			// Invalid host
+			{
+				Config: providerConfig + `resource "redirect-store_domain" "test" {
+  host = "Go.example.com:8080"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Domain`),
+			},
+		},
+	})
+}
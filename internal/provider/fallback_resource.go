@@ -0,0 +1,273 @@
+package provider
+
+import (
+	"context"
+	"experimental-terraform-redirect-store/api"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &fallbackResource{}
+	_ resource.ResourceWithConfigure      = &fallbackResource{}
+	_ resource.ResourceWithImportState    = &fallbackResource{}
+	_ resource.ResourceWithValidateConfig = &fallbackResource{}
+)
+
+// fallbackID is the id of the fallback, there is only one per server.
+const fallbackID = "fallback"
+
+// NewFallbackResource is a helper function to simplify the provider implementation.
+func NewFallbackResource() resource.Resource {
+	return &fallbackResource{}
+}
+
+// fallbackResource is the resource implementation.
+type fallbackResource struct {
+	client api.Client
+}
+
+type fallbackResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	Mode        types.String `tfsdk:"mode"`
+	To          types.String `tfsdk:"to"`
+	Record      types.String `tfsdk:"record"`
+	Suggestions types.Int64  `tfsdk:"suggestions"`
+	LastUpdated types.String `tfsdk:"last_updated"`
+}
+
+// fallback builds the fallback from the model.
+func (m fallbackResourceModel) fallback() *api.Fallback {
+	return &api.Fallback{
+		Mode:        api.FallbackMode(m.Mode.ValueString()),
+		To:          m.To.ValueString(),
+		Record:      m.Record.ValueString(),
+		Suggestions: int(m.Suggestions.ValueInt64()),
+	}
+}
+
+// optionalString returns null instead of the empty string.
+func optionalString(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(s)
+}
+
+// Metadata returns the resource type name.
+func (r *fallbackResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_fallback"
+}
+
+// Schema defines the schema for the resource.
+func (r *fallbackResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the fallback for unknown names. There is only one fallback per server; destroying it resets the mode to none.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Placeholder identifier attribute.",
+				Computed:    true,
+			},
+			"mode": schema.StringAttribute{
+				Description: "How to respond to the redirects of unknown names. " +
+					"none responds 404, redirect redirects to `to` with 302, " +
+					"suggest responds 404 with a page listing the closest names, " +
+					"record forwards to the fallback record named `record` under the longest prefix of the name.",
+				Required: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(fallbackModes()...),
+				},
+			},
+			"to": schema.StringAttribute{
+				Description: "Redirect-to of the redirect mode.",
+				Optional:    true,
+			},
+			"record": schema.StringAttribute{
+				Description: "Name of the fallback records of the record mode, e.g. `_fallback` tries `docs/_fallback` and then `_fallback` for `docs/unknown`.",
+				Optional:    true,
+			},
+			"suggestions": schema.Int64Attribute{
+				Description: fmt.Sprintf("Max number of names the suggest mode lists. Defaults to %d.", api.DefaultSuggestions),
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(api.DefaultSuggestions),
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"last_updated": schema.StringAttribute{
+				Description: "Timestamp of the last Terraform update of the fallback.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// ValidateConfig validates the attributes the mode requires.
+func (r *fallbackResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config fallbackResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Mode.IsUnknown() || config.To.IsUnknown() || config.Record.IsUnknown() || config.Suggestions.IsUnknown() {
+		return
+	}
+	if err := config.fallback().Validate(); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("mode"),
+			"Invalid Fallback",
+			err.Error(),
+		)
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *fallbackResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	// Retrieve values from plan
+	var plan fallbackResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := r.client.PutFallback(ctx, plan.fallback()); err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating fallback",
+			"Could not create fallback, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(fallbackID)
+	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *fallbackResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state fallbackResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	fallback, err := r.client.GetFallback(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading RedirectStore Fallback",
+			"Could not read RedirectStore fallback: "+err.Error(),
+		)
+		return
+	}
+
+	state.ID = types.StringValue(fallbackID)
+	state.Mode = types.StringValue(string(fallback.Mode))
+	state.To = optionalString(fallback.To)
+	state.Record = optionalString(fallback.Record)
+	state.Suggestions = types.Int64Value(int64(fallback.MaxSuggestions()))
+
+	// Set refreshed state
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *fallbackResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Retrieve values from plan
+	var plan fallbackResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := r.client.PutFallback(ctx, plan.fallback()); err != nil {
+		resp.Diagnostics.AddError(
+			"Error updating fallback",
+			"Could not update fallback, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(fallbackID)
+	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete resets the fallback and removes the Terraform state on success.
+func (r *fallbackResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state fallbackResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := r.client.PutFallback(ctx, &api.Fallback{
+		Mode: api.FallbackNone,
+	}); err != nil {
+		resp.Diagnostics.AddError(
+			"Error deleting fallback",
+			"Could not reset fallback, unexpected error: "+err.Error(),
+		)
+		return
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *fallbackResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(api.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected api.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func fallbackModes() []string {
+	modes := make([]string, len(api.FallbackModes))
+	for i, m := range api.FallbackModes {
+		modes[i] = string(m)
+	}
+	return modes
+}
+
+func (r *fallbackResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
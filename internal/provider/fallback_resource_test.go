@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccFallbackResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create fallback
+			{
+				Config: providerConfig + `resource "redirect-store_fallback" "test" {
+  mode = "suggest"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "id", "fallback"),
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "mode", "suggest"),
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "suggestions", "5"),
+				),
+			},
+			// Import state
+			{
+				ResourceName:            "redirect-store_fallback.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"last_updated"},
+			},
+			// Update to redirect
+			{
+				Config: providerConfig + `resource "redirect-store_fallback" "test" {
+  mode = "redirect"
+  to = "https://example.com/"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "mode", "redirect"),
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "to", "https://example.com/"),
+				),
+			},
+			// Update to record
+			{
+				Config: providerConfig + `resource "redirect-store_fallback" "test" {
+  mode = "record"
+  record = "_fallback"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "mode", "record"),
+					resource.TestCheckResourceAttr("redirect-store_fallback.test", "record", "_fallback"),
+					resource.TestCheckNoResourceAttr("redirect-store_fallback.test", "to"),
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccFallbackResourceInvalid(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `resource "redirect-store_fallback" "test" {
+  mode = "redirect"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Fallback`),
+			},
+		},
+	})
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -33,7 +34,12 @@ type RedirectStoreProvider struct {
 
 // RedirectStoreProviderModel describes the provider data model.
 type RedirectStoreProviderModel struct {
-	Endpoint types.String `tfsdk:"endpoint"`
+	Endpoint       types.String `tfsdk:"endpoint"`
+	Token          types.String `tfsdk:"token"`
+	CAFile         types.String `tfsdk:"ca_file"`
+	ClientCertFile types.String `tfsdk:"client_cert_file"`
+	ClientKeyFile  types.String `tfsdk:"client_key_file"`
+	Namespace      types.String `tfsdk:"namespace"`
 }
 
 func (p *RedirectStoreProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -49,6 +55,30 @@ func (p *RedirectStoreProvider) Schema(ctx context.Context, req provider.SchemaR
 				MarkdownDescription: "API endpoint",
 				Optional:            true,
 			},
+			"token": schema.StringAttribute{
+				MarkdownDescription: "Bearer token of the management API. May also be provided via the REDIRECT_STORE_TOKEN environment variable.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"ca_file": schema.StringAttribute{
+				MarkdownDescription: "PEM CA bundle verifying the API server, the system roots if not set. May also be provided via the REDIRECT_STORE_CA_FILE environment variable.",
+				Optional:            true,
+			},
+			"client_cert_file": schema.StringAttribute{
+				MarkdownDescription: "PEM client certificate for mutual TLS. May also be provided via the REDIRECT_STORE_CLIENT_CERT_FILE environment variable.",
+				Optional:            true,
+			},
+			"client_key_file": schema.StringAttribute{
+				MarkdownDescription: "PEM key of the client certificate. May also be provided via the REDIRECT_STORE_CLIENT_KEY_FILE environment variable.",
+				Optional:            true,
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of the records, the default namespace if not set. May also be provided via the REDIRECT_STORE_NAMESPACE environment variable.",
+				Optional:            true,
+				Validators: []validator.String{
+					namespaceValidator{},
+				},
+			},
 		},
 	}
 }
@@ -71,15 +101,32 @@ func (p *RedirectStoreProvider) Configure(ctx context.Context, req provider.Conf
 		)
 	}
 
+	if config.Token.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("token"),
+			"Unknown RedirectStore API Token",
+			"The provider cannot create the RedirectStore API client as there is an unknown configuration value for the RedirectStore API token. "+
+				"Either target apply the source of the value first, set the value statically in the configuration, or use the REDIRECT_STORE_TOKEN environment variable.",
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	endpoint := os.Getenv("REDIRECT_STORE_ENDPOINT")
+	token := os.Getenv("REDIRECT_STORE_TOKEN")
 
 	if !config.Endpoint.IsNull() {
 		endpoint = config.Endpoint.ValueString()
 	}
+	if !config.Token.IsNull() {
+		token = config.Token.ValueString()
+	}
+	caFile := stringOrEnv(config.CAFile, "REDIRECT_STORE_CA_FILE")
+	clientCertFile := stringOrEnv(config.ClientCertFile, "REDIRECT_STORE_CLIENT_CERT_FILE")
+	clientKeyFile := stringOrEnv(config.ClientKeyFile, "REDIRECT_STORE_CLIENT_KEY_FILE")
+	namespace := stringOrEnv(config.Namespace, "REDIRECT_STORE_NAMESPACE")
 
 	if endpoint == "" {
 		resp.Diagnostics.AddAttributeError(
@@ -91,28 +138,59 @@ func (p *RedirectStoreProvider) Configure(ctx context.Context, req provider.Conf
 		)
 	}
 
+	if err := api.ValidateNamespace(namespace); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("namespace"),
+			"Invalid RedirectStore Namespace",
+			"The provider cannot create the RedirectStore API client as the namespace is invalid: "+err.Error(),
+		)
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	tlsConfig, err := api.NewClientTLSConfig(caFile, clientCertFile, clientKeyFile)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid RedirectStore API TLS Configuration",
+			"The provider cannot create the RedirectStore API client as the TLS files cannot be loaded: "+err.Error(),
+		)
+		return
+	}
+
 	client := api.NewClientImpl(endpoint, &http.Client{
-		Timeout: 3 * time.Second,
-	})
+		Timeout:   3 * time.Second,
+		Transport: api.NewTokenTransport(token, api.NewTransport(tlsConfig)),
+	}).WithNamespace(namespace)
 	resp.DataSourceData = client
 	resp.ResourceData = client
 
-	tflog.Info(ctx, "Configured RedirectStore client", map[string]any{"endpoint": endpoint})
+	tflog.Info(ctx, "Configured RedirectStore client", map[string]any{"endpoint": endpoint, "token": token != "", "namespace": namespace})
+}
+
+// stringOrEnv returns the configured value, the environment variable if not configured.
+func stringOrEnv(v types.String, key string) string {
+	if !v.IsNull() {
+		return v.ValueString()
+	}
+	return os.Getenv(key)
 }
 
 func (p *RedirectStoreProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewRecordResource,
+		NewFallbackResource,
+		NewDomainResource,
 	}
 }
 
 func (p *RedirectStoreProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewRecordsDataSource,
+		NewRecordDataSource,
+		NewRecordStatsDataSource,
+		NewRecordHistoryDataSource,
 	}
 }
 
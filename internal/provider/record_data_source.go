@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"experimental-terraform-redirect-store/api"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = &recordDataSource{}
+	_ datasource.DataSourceWithConfigure = &recordDataSource{}
+)
+
+func NewRecordDataSource() datasource.DataSource {
+	return &recordDataSource{}
+}
+
+type recordDataSource struct {
+	client api.Client
+}
+
+type recordDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Namespace  types.String `tfsdk:"namespace"`
+	Name       types.String `tfsdk:"name"`
+	To         types.String `tfsdk:"to"`
+	StatusCode types.Int64  `tfsdk:"status_code"`
+	Match      types.String `tfsdk:"match"`
+	Query      types.String `tfsdk:"query"`
+	NotBefore  types.String `tfsdk:"not_before"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
+	Version    types.Int64  `tfsdk:"version"`
+}
+
+func (d *recordDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_record"
+}
+
+func (d *recordDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Fetch a record by name.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Placeholder identifier attribute.",
+				Computed:    true,
+			},
+			"namespace": schema.StringAttribute{
+				Description: "Namespace of the record. Defaults to the namespace of the provider.",
+				Optional:    true,
+				Computed:    true,
+				Validators: []validator.String{
+					namespaceValidator{},
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Record name.",
+				Required:    true,
+			},
+			"to": schema.StringAttribute{
+				Description: "Record redirect-to.",
+				Computed:    true,
+			},
+			"status_code": schema.Int64Attribute{
+				Description: "HTTP status code of the redirect.",
+				Computed:    true,
+			},
+			"match": schema.StringAttribute{
+				Description: "How the record matches the requested name, exact or prefix.",
+				Computed:    true,
+			},
+			"query": schema.StringAttribute{
+				Description: "What to do with the query string of the request.",
+				Computed:    true,
+			},
+			"not_before": schema.StringAttribute{
+				Description: "RFC3339 timestamp when the record starts redirecting.",
+				Computed:    true,
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "RFC3339 timestamp when the record stops redirecting.",
+				Computed:    true,
+			},
+			"version": schema.Int64Attribute{
+				Description: "Version of the record on the server.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *recordDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state recordDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client := d.client
+	if !state.Namespace.IsNull() {
+		client = client.WithNamespace(state.Namespace.ValueString())
+	}
+	record, err := client.Get(ctx, state.Name.ValueString())
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"RedirectStore Record Not Found",
+			fmt.Sprintf("No record named %q exists in the namespace %q.", state.Name.ValueString(), client.Namespace()),
+		)
+		return
+	case err != nil:
+		resp.Diagnostics.AddError(
+			"Unable to Read RedirectStore Record",
+			"Could not read RedirectStore record name "+state.Name.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	state.ID = recordID(record.Namespace, record.Name)
+	state.Namespace = types.StringValue(record.Namespace)
+	state.Name = types.StringValue(record.Name)
+	state.To = types.StringValue(record.To)
+	state.StatusCode = types.Int64Value(int64(record.RedirectStatusCode()))
+	state.Match = types.StringValue(string(record.MatchMode()))
+	state.Query = types.StringValue(string(record.QueryPolicy()))
+	state.NotBefore = timeValue(types.StringNull(), record.NotBefore)
+	state.ExpiresAt = timeValue(types.StringNull(), record.ExpiresAt)
+	state.Version = types.Int64Value(record.Version)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (d *recordDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(api.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected api.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccRecordDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test" {
+  name = "lookup-name"
+  to = "https://example.com/lookup"
+  status_code = 302
+}
+
+data "redirect-store_record" "test" {
+  name = redirect-store_record.test.name
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.redirect-store_record.test", "id", "lookup-name"),
+					resource.TestCheckResourceAttr("data.redirect-store_record.test", "to", "https://example.com/lookup"),
+					resource.TestCheckResourceAttr("data.redirect-store_record.test", "status_code", "302"),
+					resource.TestCheckResourceAttr("data.redirect-store_record.test", "match", "exact"),
+					resource.TestCheckResourceAttr("data.redirect-store_record.test", "version", "1"),
+					resource.TestCheckNoResourceAttr("data.redirect-store_record.test", "expires_at"),
+				),
+			},
+			{
+				Config: providerConfig + `data "redirect-store_record" "missing" {
+  name = "missing-name"
+}`,
+				ExpectError: regexp.MustCompile(`RedirectStore Record Not Found`),
+			},
+		},
+	})
+}
@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"experimental-terraform-redirect-store/api"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = &recordHistoryDataSource{}
+	_ datasource.DataSourceWithConfigure = &recordHistoryDataSource{}
+)
+
+func NewRecordHistoryDataSource() datasource.DataSource {
+	return &recordHistoryDataSource{}
+}
+
+type recordHistoryDataSource struct {
+	client api.Client
+}
+
+type recordHistoryDataSourceModel struct {
+	ID        types.String    `tfsdk:"id"`
+	Name      types.String    `tfsdk:"name"`
+	Revisions []revisionModel `tfsdk:"revisions"`
+}
+
+type revisionModel struct {
+	Revision types.Int64          `tfsdk:"revision"`
+	Op       types.String         `tfsdk:"op"`
+	Time     types.String         `tfsdk:"time"`
+	Actor    types.String         `tfsdk:"actor"`
+	Rollback types.Int64          `tfsdk:"rollback"`
+	Old      *revisionRecordModel `tfsdk:"old"`
+	New      *revisionRecordModel `tfsdk:"new"`
+}
+
+type revisionRecordModel struct {
+	To         types.String `tfsdk:"to"`
+	StatusCode types.Int64  `tfsdk:"status_code"`
+	Match      types.String `tfsdk:"match"`
+	Query      types.String `tfsdk:"query"`
+	NotBefore  types.String `tfsdk:"not_before"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
+}
+
+func newRevisionRecordModel(record *api.Record) *revisionRecordModel {
+	if record == nil {
+		return nil
+	}
+	return &revisionRecordModel{
+		To:         types.StringValue(record.To),
+		StatusCode: types.Int64Value(int64(record.RedirectStatusCode())),
+		Match:      types.StringValue(string(record.MatchMode())),
+		Query:      types.StringValue(string(record.QueryPolicy())),
+		NotBefore:  timeValue(types.StringNull(), record.NotBefore),
+		ExpiresAt:  timeValue(types.StringNull(), record.ExpiresAt),
+	}
+}
+
+// revisionRecordAttributes are the attributes of the record before and after a revision.
+func revisionRecordAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"to": schema.StringAttribute{
+			Description: "Record redirect-to.",
+			Computed:    true,
+		},
+		"status_code": schema.Int64Attribute{
+			Description: "HTTP status code of the redirect.",
+			Computed:    true,
+		},
+		"match": schema.StringAttribute{
+			Description: "How the record matches the requested name, exact or prefix.",
+			Computed:    true,
+		},
+		"query": schema.StringAttribute{
+			Description: "What to do with the query string of the request.",
+			Computed:    true,
+		},
+		"not_before": schema.StringAttribute{
+			Description: "RFC3339 timestamp when the record starts redirecting.",
+			Computed:    true,
+		},
+		"expires_at": schema.StringAttribute{
+			Description: "RFC3339 timestamp when the record stops redirecting.",
+			Computed:    true,
+		},
+	}
+}
+
+func (d *recordHistoryDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_record_history"
+}
+
+func (d *recordHistoryDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Fetch the revisions of a record, oldest first.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Placeholder identifier attribute.",
+				Computed:    true,
+			},
+			"name": schema.StringAttribute{
+				Description: "Record name.",
+				Required:    true,
+			},
+			"revisions": schema.ListNestedAttribute{
+				Description: "Changes of the record.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"revision": schema.Int64Attribute{
+							Description: "Sequence number of the revision, starting from 1.",
+							Computed:    true,
+						},
+						"op": schema.StringAttribute{
+							Description: "Change of the revision, put or delete.",
+							Computed:    true,
+						},
+						"time": schema.StringAttribute{
+							Description: "RFC3339 timestamp of the change.",
+							Computed:    true,
+						},
+						"actor": schema.StringAttribute{
+							Description: "Name of the token of the change, null if the server does not require tokens.",
+							Computed:    true,
+						},
+						"rollback": schema.Int64Attribute{
+							Description: "Revision the change rolled back to, null if the change is not a rollback.",
+							Computed:    true,
+						},
+						"old": schema.SingleNestedAttribute{
+							Description: "Record before the change, null if the record did not exist.",
+							Computed:    true,
+							Attributes:  revisionRecordAttributes(),
+						},
+						"new": schema.SingleNestedAttribute{
+							Description: "Record after the change, null if the record was deleted.",
+							Computed:    true,
+							Attributes:  revisionRecordAttributes(),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *recordHistoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state recordHistoryDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	revisions, err := d.client.History(ctx, state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read RedirectStore Record History",
+			err.Error(),
+		)
+		return
+	}
+
+	state.ID = recordID(d.client.Namespace(), state.Name.ValueString())
+	state.Revisions = []revisionModel{}
+	for _, r := range revisions {
+		m := revisionModel{
+			Revision: types.Int64Value(int64(r.Revision)),
+			Op:       types.StringValue(string(r.Op)),
+			Time:     types.StringValue(r.Time.Format(time.RFC3339)),
+			Actor:    types.StringNull(),
+			Rollback: types.Int64Null(),
+			Old:      newRevisionRecordModel(r.Old),
+			New:      newRevisionRecordModel(r.New),
+		}
+		if r.Actor != "" {
+			m.Actor = types.StringValue(r.Actor)
+		}
+		if r.Rollback != 0 {
+			m.Rollback = types.Int64Value(int64(r.Rollback))
+		}
+		state.Revisions = append(state.Revisions, m)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (d *recordHistoryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(api.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected api.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
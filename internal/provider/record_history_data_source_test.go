@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccRecordHistoryDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test" {
+  name = "history-name"
+  to = "https://example.com/history"
+}
+
+data "redirect-store_record_history" "test" {
+  name = redirect-store_record.test.name
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.redirect-store_record_history.test", "name", "history-name"),
+					resource.TestCheckResourceAttr("data.redirect-store_record_history.test", "revisions.#", "1"),
+					resource.TestCheckResourceAttr("data.redirect-store_record_history.test", "revisions.0.revision", "1"),
+					resource.TestCheckResourceAttr("data.redirect-store_record_history.test", "revisions.0.op", "put"),
+					resource.TestCheckResourceAttr("data.redirect-store_record_history.test", "revisions.0.new.to", "https://example.com/history"),
+					resource.TestCheckNoResourceAttr("data.redirect-store_record_history.test", "revisions.0.old.to"),
+				),
+			},
+		},
+	})
+}
@@ -2,23 +2,31 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"experimental-terraform-redirect-store/api"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &recordResource{}
-	_ resource.ResourceWithConfigure   = &recordResource{}
-	_ resource.ResourceWithImportState = &recordResource{}
+	_ resource.Resource                   = &recordResource{}
+	_ resource.ResourceWithConfigure      = &recordResource{}
+	_ resource.ResourceWithImportState    = &recordResource{}
+	_ resource.ResourceWithValidateConfig = &recordResource{}
 )
 
 // NewRecordResource is a helper function to simplify the provider implementation.
@@ -33,11 +41,68 @@ type recordResource struct {
 
 type recordResourceModel struct {
 	ID          types.String `tfsdk:"id"`
+	Namespace   types.String `tfsdk:"namespace"`
 	Name        types.String `tfsdk:"name"`
 	To          types.String `tfsdk:"to"`
+	StatusCode  types.Int64  `tfsdk:"status_code"`
+	Match       types.String `tfsdk:"match"`
+	Query       types.String `tfsdk:"query"`
+	NotBefore   types.String `tfsdk:"not_before"`
+	ExpiresAt   types.String `tfsdk:"expires_at"`
+	Version     types.Int64  `tfsdk:"version"`
 	LastUpdated types.String `tfsdk:"last_updated"`
 }
 
+// record builds the record from the model.
+func (m recordResourceModel) record() (*api.Record, error) {
+	notBefore, err := parseTime(m.NotBefore.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("not_before: %w", err)
+	}
+	expiresAt, err := parseTime(m.ExpiresAt.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("expires_at: %w", err)
+	}
+	return &api.Record{
+		Namespace:  m.Namespace.ValueString(),
+		Name:       m.Name.ValueString(),
+		To:         m.To.ValueString(),
+		StatusCode: int(m.StatusCode.ValueInt64()),
+		Match:      api.MatchMode(m.Match.ValueString()),
+		Query:      api.QueryPolicy(m.Query.ValueString()),
+		NotBefore:  notBefore,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// recordID returns the name for the default namespace, namespace:name otherwise.
+func recordID(namespace, name string) types.String {
+	if namespace == api.DefaultNamespace {
+		return types.StringValue(name)
+	}
+	return types.StringValue(namespace + ":" + name)
+}
+
+// parseRecordID parses the output of recordID.
+func parseRecordID(id string) (string, string) {
+	if namespace, name, ok := strings.Cut(id, ":"); ok && namespace != "" && api.ValidateNamespace(namespace) == nil {
+		return namespace, name
+	}
+	return api.DefaultNamespace, id
+}
+
+// timeValue returns the time as a RFC3339 string,
+// keeping the current value if it denotes the same time.
+func timeValue(current types.String, t *time.Time) types.String {
+	if t == nil {
+		return types.StringNull()
+	}
+	if c, err := parseTime(current.ValueString()); err == nil && c != nil && c.Equal(*t) {
+		return current
+	}
+	return types.StringValue(t.Format(time.RFC3339))
+}
+
 // Metadata returns the resource type name.
 func (r *recordResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_record"
@@ -52,16 +117,84 @@ func (r *recordResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 				Description: "Placeholder identifier attribute.",
 				Computed:    true,
 			},
+			"namespace": schema.StringAttribute{
+				Description: "Namespace of the record. Defaults to the namespace of the provider. Changing it replaces the record.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					namespaceValidator{},
+				},
+			},
 			"name": schema.StringAttribute{
-				Description: "Record name.",
-				Required:    true,
+				Description: "Record name. A name with parameters like `gh/{org}/{repo}` is a pattern matching any value of each parameter segment. " +
+					"It consists of alphanumerics and `-._~/{}`, not starting with a slash. Changing it replaces the record.",
+				Required: true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					nameValidator{},
 				},
 			},
 			"to": schema.StringAttribute{
-				Description: "Record redirect-to.",
+				Description: "Record redirect-to, an absolute http or https URL. If the name is a pattern, `{param}` is replaced with the value captured by the parameter.",
 				Required:    true,
+				Validators: []validator.String{
+					toValidator{},
+				},
+			},
+			"status_code": schema.Int64Attribute{
+				Description: "HTTP status code of the redirect, one of 301, 302, 303, 307 and 308. Defaults to 301.",
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(api.DefaultStatusCode),
+				Validators: []validator.Int64{
+					int64validator.OneOf(statusCodes()...),
+				},
+			},
+			"match": schema.StringAttribute{
+				Description: "How the record matches the requested name, exact or prefix. " +
+					"A prefix record also redirects the names under it, appending the rest of the path to the redirect-to. Defaults to exact.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(string(api.MatchExact)),
+				Validators: []validator.String{
+					stringvalidator.OneOf(matchModes()...),
+				},
+			},
+			"query": schema.StringAttribute{
+				Description: "What to do with the query string of the request. " +
+					"drop discards it, pass appends it to the query string of the redirect-to as is, " +
+					"merge_request and merge_target merge them, preferring the request or the redirect-to respectively for the same key. Defaults to drop.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(string(api.QueryDrop)),
+				Validators: []validator.String{
+					stringvalidator.OneOf(queryPolicies()...),
+				},
+			},
+			"not_before": schema.StringAttribute{
+				Description: "RFC3339 timestamp when the record starts redirecting.",
+				Optional:    true,
+				Validators: []validator.String{
+					rfc3339Validator{},
+				},
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "RFC3339 timestamp when the record stops redirecting.",
+				Optional:    true,
+				Validators: []validator.String{
+					rfc3339Validator{},
+				},
+			},
+			"version": schema.Int64Attribute{
+				Description: "Version of the record on the server. The record is changed only at this version, so that changes outside Terraform since the last refresh are errors instead of being overwritten.",
+				Computed:    true,
 			},
 			"last_updated": schema.StringAttribute{
 				Description: "Timestamp of the last Terraform update of the record.",
@@ -71,6 +204,38 @@ func (r *recordResource) Schema(_ context.Context, _ resource.SchemaRequest, res
 	}
 }
 
+// ValidateConfig validates the pattern and the template of the record.
+func (r *recordResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config recordResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.Name.IsUnknown() || config.Name.IsNull() || config.To.IsUnknown() || config.To.IsNull() {
+		return
+	}
+	name := config.Name.ValueString()
+	if !api.IsPattern(name) {
+		return
+	}
+	if err := api.ValidatePattern(name, config.To.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("to"),
+			"Invalid Record Pattern",
+			err.Error(),
+		)
+	}
+	if !config.Match.IsUnknown() && !config.Match.IsNull() && config.Match.ValueString() != string(api.MatchExact) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("match"),
+			"Invalid Record Pattern",
+			"A pattern record must match exactly.",
+		)
+	}
+}
+
 // Create creates the resource and sets the initial Terraform state.
 func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	// Retrieve values from plan
@@ -81,12 +246,25 @@ func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	record := &api.Record{
-		Name: plan.Name.ValueString(),
-		To:   plan.To.ValueString(),
+	record, err := plan.record()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid record",
+			err.Error(),
+		)
+		return
 	}
 
-	if _, err := r.client.Put(ctx, record); err != nil {
+	client := r.clientFor(plan.Namespace)
+	put, err := client.PutIf(ctx, record, api.Precondition{IfAbsent: true})
+	if errors.Is(err, api.ErrConflict) {
+		resp.Diagnostics.AddError(
+			"Record already exists",
+			"Could not create record "+plan.Name.ValueString()+" because it already exists; import it to manage it with Terraform: "+err.Error(),
+		)
+		return
+	}
+	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error creating record",
 			"Could not create record, unexpected error: "+err.Error(),
@@ -94,7 +272,9 @@ func (r *recordResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	plan.ID = plan.Name
+	plan.Version = types.Int64Value(put.Version)
+	plan.Namespace = types.StringValue(client.Namespace())
+	plan.ID = recordID(client.Namespace(), plan.Name.ValueString())
 	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -112,7 +292,12 @@ func (r *recordResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	record, err := r.client.Get(ctx, state.Name.ValueString())
+	record, err := r.clientFor(state.Namespace).Get(ctx, state.Name.ValueString())
+	if errors.Is(err, api.ErrNotFound) {
+		// deleted outside Terraform, plan to create it again
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Reading RedirectStore Record",
@@ -121,9 +306,16 @@ func (r *recordResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
+	state.Namespace = types.StringValue(record.Namespace)
 	state.Name = types.StringValue(record.Name)
-	state.ID = state.Name
+	state.ID = recordID(record.Namespace, record.Name)
 	state.To = types.StringValue(record.To)
+	state.StatusCode = types.Int64Value(int64(record.RedirectStatusCode()))
+	state.Match = types.StringValue(string(record.MatchMode()))
+	state.Query = types.StringValue(string(record.QueryPolicy()))
+	state.NotBefore = timeValue(state.NotBefore, record.NotBefore)
+	state.ExpiresAt = timeValue(state.ExpiresAt, record.ExpiresAt)
+	state.Version = types.Int64Value(record.Version)
 
 	// Set refreshed state
 	diags = resp.State.Set(ctx, &state)
@@ -143,12 +335,32 @@ func (r *recordResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	record := &api.Record{
-		Name: plan.Name.ValueString(),
-		To:   plan.To.ValueString(),
+	record, err := plan.record()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid record",
+			err.Error(),
+		)
+		return
+	}
+
+	var state recordResourceModel
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	if _, err := r.client.Put(ctx, record); err != nil {
+	client := r.clientFor(plan.Namespace)
+	put, err := client.PutIf(ctx, record, api.Precondition{IfVersion: state.Version.ValueInt64()})
+	if errors.Is(err, api.ErrConflict) {
+		resp.Diagnostics.AddError(
+			"Record changed outside Terraform",
+			"Could not update record "+plan.Name.ValueString()+" because it changed since the last refresh; refresh and apply again: "+err.Error(),
+		)
+		return
+	}
+	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error updating record",
 			"Could not update record, unexpected error: "+err.Error(),
@@ -156,7 +368,9 @@ func (r *recordResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	plan.ID = plan.Name
+	plan.Version = types.Int64Value(put.Version)
+	plan.Namespace = types.StringValue(client.Namespace())
+	plan.ID = recordID(client.Namespace(), plan.Name.ValueString())
 	plan.LastUpdated = types.StringValue(time.Now().Format(time.RFC850))
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -174,7 +388,19 @@ func (r *recordResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	if err := r.client.Delete(ctx, state.Name.ValueString()); err != nil {
+	err := r.clientFor(state.Namespace).DeleteIf(ctx, state.Name.ValueString(), api.Precondition{IfVersion: state.Version.ValueInt64()})
+	if errors.Is(err, api.ErrNotFound) {
+		// already deleted outside Terraform
+		return
+	}
+	if errors.Is(err, api.ErrConflict) {
+		resp.Diagnostics.AddError(
+			"Record changed outside Terraform",
+			"Could not delete record "+state.Name.ValueString()+" because it changed since the last refresh; refresh and apply again: "+err.Error(),
+		)
+		return
+	}
+	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error deleting record",
 			"Could not delete record, unexpected error: "+err.Error(),
@@ -183,6 +409,14 @@ func (r *recordResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 }
 
+// clientFor returns the client of the namespace, the client of the provider if the namespace is not set.
+func (r *recordResource) clientFor(namespace types.String) api.Client {
+	if namespace.IsNull() || namespace.IsUnknown() {
+		return r.client
+	}
+	return r.client.WithNamespace(namespace.ValueString())
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *recordResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -203,6 +437,35 @@ func (r *recordResource) Configure(_ context.Context, req resource.ConfigureRequ
 	r.client = client
 }
 
+func statusCodes() []int64 {
+	codes := make([]int64, len(api.StatusCodes))
+	for i, c := range api.StatusCodes {
+		codes[i] = int64(c)
+	}
+	return codes
+}
+
+func matchModes() []string {
+	modes := make([]string, len(api.MatchModes))
+	for i, m := range api.MatchModes {
+		modes[i] = string(m)
+	}
+	return modes
+}
+
+func queryPolicies() []string {
+	policies := make([]string, len(api.QueryPolicies))
+	for i, q := range api.QueryPolicies {
+		policies[i] = string(q)
+	}
+	return policies
+}
+
+// ImportState imports the record by the name in the namespace of the provider, or by namespace:name.
 func (r *recordResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+	namespace, name := parseRecordID(req.ID)
+	if namespace != api.DefaultNamespace {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("namespace"), namespace)...)
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
 }
@@ -1,12 +1,21 @@
 package provider
 
 import (
+	"context"
+	"errors"
+	"experimental-terraform-redirect-store/api"
+	"fmt"
+	"net/http"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
 func TestAccRecordResource(t *testing.T) {
+	client := api.NewClientImpl("http://127.0.0.1:8030", http.DefaultClient)
 	resource.Test(t, resource.TestCase{
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
@@ -14,11 +23,15 @@ func TestAccRecordResource(t *testing.T) {
 			{
 				Config: providerConfig + `resource "redirect-store_record" "test0" {
   name = "test0-name"
-  to = "test0-to"
+  to = "https://example.com/test0"
 }`,
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("redirect-store_record.test0", "name", "test0-name"),
-					resource.TestCheckResourceAttr("redirect-store_record.test0", "to", "test0-to"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "to", "https://example.com/test0"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "status_code", "301"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "match", "exact"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "query", "drop"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "version", "1"),
 				),
 			},
 			// Import state
@@ -34,13 +47,284 @@ func TestAccRecordResource(t *testing.T) {
 			{
 				Config: providerConfig + `resource "redirect-store_record" "test0" {
   name = "test0-name"
-  to = "test0-to-changed"
+  to = "https://example.com/test0-changed"
 }`,
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("redirect-store_record.test0", "name", "test0-name"),
-					resource.TestCheckResourceAttr("redirect-store_record.test0", "to", "test0-to-changed"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "to", "https://example.com/test0-changed"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "version", "2"),
 				),
 			},
+			// Update status code
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-name"
+  to = "https://example.com/test0-changed"
+  status_code = 302
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "to", "https://example.com/test0-changed"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "status_code", "302"),
+				),
+			},
+			// Update match and query
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-name"
+  to = "https://example.com/test0-changed"
+  status_code = 302
+  match = "prefix"
+  query = "merge_request"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "match", "prefix"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "query", "merge_request"),
+				),
+			},
+			// Update window
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-name"
+  to = "https://example.com/test0-changed"
+  status_code = 302
+  match = "prefix"
+  query = "merge_request"
+  not_before = "2024-01-01T00:00:00Z"
+  expires_at = "2024-02-01T09:00:00+09:00"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "not_before", "2024-01-01T00:00:00Z"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "expires_at", "2024-02-01T09:00:00+09:00"),
+				),
+			},
+			// Invalid timestamp
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-name"
+  to = "https://example.com/test0-changed"
+  expires_at = "2024-02-01"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid RFC3339 Timestamp`),
+			},
+			// Invalid status code
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-name"
+  to = "https://example.com/test0-changed"
+  status_code = 200
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Attribute Value Match`),
+			},
+			// Invalid name
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "/test0 name"
+  to = "https://example.com/test0-changed"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Name`),
+			},
+			// Invalid to
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-name"
+  to = "example.com/test0-changed"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid To`),
+			},
+			// Rename replaces the record
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test0" {
+  name = "test0-renamed"
+  to = "https://example.com/test0-changed"
+}`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("redirect-store_record.test0", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "name", "test0-renamed"),
+					resource.TestCheckResourceAttr("redirect-store_record.test0", "version", "1"),
+					func(*terraform.State) error {
+						if _, err := client.Get(context.Background(), "test0-name"); !errors.Is(err, api.ErrNotFound) {
+							return fmt.Errorf("want the old record deleted, got %v", err)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccRecordResourcePattern(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create pattern record
+			{
+				Config: providerConfig + `resource "redirect-store_record" "pattern0" {
+  name = "pattern0/{org}/{repo}"
+  to = "https://github.com/{org}/{repo}"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.pattern0", "name", "pattern0/{org}/{repo}"),
+					resource.TestCheckResourceAttr("redirect-store_record.pattern0", "to", "https://github.com/{org}/{repo}"),
+				),
+			},
+			// Unknown parameter
+			{
+				Config: providerConfig + `resource "redirect-store_record" "pattern0" {
+  name = "pattern0/{org}/{repo}"
+  to = "https://github.com/{user}"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Record Pattern`),
+			},
+			// Malformed template
+			{
+				Config: providerConfig + `resource "redirect-store_record" "pattern0" {
+  name = "pattern0/{org}/{repo}"
+  to = "https://github.com/{org"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Record Pattern`),
+			},
+		},
+	})
+}
+
+func TestAccRecordResourceNamespace(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create records of the same name in the namespaces
+			{
+				Config: providerConfig + `resource "redirect-store_record" "ns0" {
+  name = "ns0-name"
+  to = "https://example.com/ns0"
+}
+
+resource "redirect-store_record" "ns1" {
+  namespace = "team"
+  name = "ns0-name"
+  to = "https://example.com/ns1"
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.ns0", "namespace", ""),
+					resource.TestCheckResourceAttr("redirect-store_record.ns0", "id", "ns0-name"),
+					resource.TestCheckResourceAttr("redirect-store_record.ns1", "namespace", "team"),
+					resource.TestCheckResourceAttr("redirect-store_record.ns1", "id", "team:ns0-name"),
+					resource.TestCheckResourceAttr("redirect-store_record.ns1", "to", "https://example.com/ns1"),
+				),
+			},
+			// Import state
+			{
+				ResourceName:            "redirect-store_record.ns1",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"last_updated"},
+			},
+			// Invalid namespace
+			{
+				Config: providerConfig + `resource "redirect-store_record" "ns1" {
+  namespace = "Team"
+  name = "ns0-name"
+  to = "https://example.com/ns1"
+}`,
+				ExpectError: regexp.MustCompile(`Invalid Namespace`),
+			},
+		},
+	})
+}
+
+func TestAccRecordResourceConflict(t *testing.T) {
+	client := api.NewClientImpl("http://127.0.0.1:8030", http.DefaultClient)
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create over a record outside Terraform
+			{
+				PreConfig: func() {
+					if _, err := client.Put(context.Background(), &api.Record{Name: "conflict-name", To: "https://example.com/conflict"}); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: providerConfig + `resource "redirect-store_record" "conflict" {
+  name = "conflict-name"
+  to = "https://example.com/conflict-terraform"
+}`,
+				ExpectError: regexp.MustCompile("Record already exists"),
+			},
+			// Delete the record outside Terraform and create it
+			{
+				PreConfig: func() {
+					if err := client.Delete(context.Background(), "conflict-name"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: providerConfig + `resource "redirect-store_record" "conflict" {
+  name = "conflict-name"
+  to = "https://example.com/conflict-terraform"
+}`,
+				Check: resource.TestCheckResourceAttr("redirect-store_record.conflict", "version", "1"),
+			},
+		},
+	})
+}
+
+func TestAccRecordResourceDrift(t *testing.T) {
+	client := api.NewClientImpl("http://127.0.0.1:8030", http.DefaultClient)
+	config := providerConfig + `resource "redirect-store_record" "drift" {
+  name = "drift-name"
+  to = "https://example.com/drift"
+}`
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  resource.TestCheckResourceAttr("redirect-store_record.drift", "version", "1"),
+			},
+			// Delete the record outside Terraform, then refresh plans to create it again
+			{
+				PreConfig: func() {
+					if err := client.Delete(context.Background(), "drift-name"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.drift", "to", "https://example.com/drift"),
+					resource.TestCheckResourceAttr("redirect-store_record.drift", "version", "1"),
+				),
+			},
+			// Modify the record outside Terraform, then apply restores it
+			{
+				PreConfig: func() {
+					if _, err := client.Put(context.Background(), &api.Record{Name: "drift-name", To: "https://example.com/drift-outside"}); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("redirect-store_record.drift", "to", "https://example.com/drift"),
+					resource.TestCheckResourceAttr("redirect-store_record.drift", "version", "3"),
+				),
+			},
+			// Delete the record outside Terraform before destroying it
+			{
+				PreConfig: func() {
+					if err := client.Delete(context.Background(), "drift-name"); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config:  config,
+				Destroy: true,
+			},
 		},
 	})
 }
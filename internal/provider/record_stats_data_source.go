@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"experimental-terraform-redirect-store/api"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSource              = &recordStatsDataSource{}
+	_ datasource.DataSourceWithConfigure = &recordStatsDataSource{}
+)
+
+func NewRecordStatsDataSource() datasource.DataSource {
+	return &recordStatsDataSource{}
+}
+
+type recordStatsDataSource struct {
+	client api.Client
+}
+
+type recordStatsDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	Name      types.String `tfsdk:"name"`
+	Total     types.Int64  `tfsdk:"total"`
+	FirstHit  types.String `tfsdk:"first_hit"`
+	LastHit   types.String `tfsdk:"last_hit"`
+	Referrers types.Map    `tfsdk:"referrers"`
+	Agents    types.Map    `tfsdk:"agents"`
+}
+
+func (d *recordStatsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_record_stats"
+}
+
+func (d *recordStatsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Fetch the hit counters of a record.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "Placeholder identifier attribute.",
+				Computed:    true,
+			},
+			"name": schema.StringAttribute{
+				Description: "Record name.",
+				Required:    true,
+			},
+			"total": schema.Int64Attribute{
+				Description: "Number of the redirects by the record.",
+				Computed:    true,
+			},
+			"first_hit": schema.StringAttribute{
+				Description: "RFC3339 timestamp of the first redirect.",
+				Computed:    true,
+			},
+			"last_hit": schema.StringAttribute{
+				Description: "RFC3339 timestamp of the last redirect.",
+				Computed:    true,
+			},
+			"referrers": schema.MapAttribute{
+				Description: "Number of the redirects by referrer host, `direct` for the redirects without a referrer.",
+				ElementType: types.Int64Type,
+				Computed:    true,
+			},
+			"agents": schema.MapAttribute{
+				Description: "Number of the redirects by user-agent class, browser, mobile, bot, cli or unknown.",
+				ElementType: types.Int64Type,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *recordStatsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state recordStatsDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	stats, err := d.client.Stats(ctx, state.Name.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read RedirectStore Record Stats",
+			err.Error(),
+		)
+		return
+	}
+
+	referrers := make(map[string]int64, len(stats.Referrers))
+	for k, v := range stats.Referrers {
+		referrers[k] = int64(v)
+	}
+	agents := make(map[string]int64, len(stats.Agents))
+	for k, v := range stats.Agents {
+		agents[string(k)] = int64(v)
+	}
+
+	state.ID = state.Name
+	state.Total = types.Int64Value(int64(stats.Total))
+	state.FirstHit = timeValue(types.StringNull(), stats.FirstHit)
+	state.LastHit = timeValue(types.StringNull(), stats.LastHit)
+	state.Referrers, diags = types.MapValueFrom(ctx, types.Int64Type, referrers)
+	resp.Diagnostics.Append(diags...)
+	state.Agents, diags = types.MapValueFrom(ctx, types.Int64Type, agents)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+func (d *recordStatsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(api.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected api.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
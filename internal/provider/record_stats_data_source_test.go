@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccRecordStatsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test" {
+  name = "stats-name"
+  to = "https://example.com/stats"
+}
+
+data "redirect-store_record_stats" "test" {
+  name = redirect-store_record.test.name
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.redirect-store_record_stats.test", "name", "stats-name"),
+					resource.TestCheckResourceAttr("data.redirect-store_record_stats.test", "total", "0"),
+					resource.TestCheckResourceAttr("data.redirect-store_record_stats.test", "referrers.%", "0"),
+					resource.TestCheckNoResourceAttr("data.redirect-store_record_stats.test", "last_hit"),
+				),
+			},
+		},
+	})
+}
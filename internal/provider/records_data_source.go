@@ -25,13 +25,22 @@ type recordsDataSource struct {
 }
 
 type recordsDataSourceModel struct {
-	Records []recordsModel `tfsdk:"records"`
+	NamePrefix types.String   `tfsdk:"name_prefix"`
+	ToContains types.String   `tfsdk:"to_contains"`
+	Records    []recordsModel `tfsdk:"records"`
 }
 
 type recordsModel struct {
-	ID   types.String `tfsdk:"id"`
-	Name types.String `tfsdk:"name"`
-	To   types.String `tfsdk:"to"`
+	ID         types.String `tfsdk:"id"`
+	Namespace  types.String `tfsdk:"namespace"`
+	Name       types.String `tfsdk:"name"`
+	To         types.String `tfsdk:"to"`
+	StatusCode types.Int64  `tfsdk:"status_code"`
+	Match      types.String `tfsdk:"match"`
+	Query      types.String `tfsdk:"query"`
+	NotBefore  types.String `tfsdk:"not_before"`
+	ExpiresAt  types.String `tfsdk:"expires_at"`
+	Version    types.Int64  `tfsdk:"version"`
 }
 
 func (d *recordsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -40,8 +49,16 @@ func (d *recordsDataSource) Metadata(_ context.Context, req datasource.MetadataR
 
 func (d *recordsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		Description: "Fetch the list of records.",
+		Description: "Fetch the list of records in the namespace of the provider, filtered on the server.",
 		Attributes: map[string]schema.Attribute{
+			"name_prefix": schema.StringAttribute{
+				Description: "Select the records whose name starts with the prefix.",
+				Optional:    true,
+			},
+			"to_contains": schema.StringAttribute{
+				Description: "Select the records whose redirect-to contains the string.",
+				Optional:    true,
+			},
 			"records": schema.ListNestedAttribute{
 				Computed: true,
 				NestedObject: schema.NestedAttributeObject{
@@ -50,6 +67,10 @@ func (d *recordsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest
 							Description: "Placeholder identifier attribute.",
 							Computed:    true,
 						},
+						"namespace": schema.StringAttribute{
+							Description: "Namespace of the record.",
+							Computed:    true,
+						},
 						"name": schema.StringAttribute{
 							Description: "Record name.",
 							Computed:    true,
@@ -58,6 +79,30 @@ func (d *recordsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest
 							Description: "Record redirect-to.",
 							Computed:    true,
 						},
+						"status_code": schema.Int64Attribute{
+							Description: "HTTP status code of the redirect.",
+							Computed:    true,
+						},
+						"match": schema.StringAttribute{
+							Description: "How the record matches the requested name, exact or prefix.",
+							Computed:    true,
+						},
+						"query": schema.StringAttribute{
+							Description: "What to do with the query string of the request.",
+							Computed:    true,
+						},
+						"not_before": schema.StringAttribute{
+							Description: "RFC3339 timestamp when the record starts redirecting.",
+							Computed:    true,
+						},
+						"expires_at": schema.StringAttribute{
+							Description: "RFC3339 timestamp when the record stops redirecting.",
+							Computed:    true,
+						},
+						"version": schema.Int64Attribute{
+							Description: "Version of the record on the server.",
+							Computed:    true,
+						},
 					},
 				},
 			},
@@ -67,8 +112,16 @@ func (d *recordsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest
 
 func (d *recordsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var state recordsDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	records, err := d.client.Scan(ctx)
+	records, err := d.client.ScanFiltered(ctx, api.ScanFilter{
+		NamePrefix: state.NamePrefix.ValueString(),
+		ToContains: state.ToContains.ValueString(),
+	})
 	switch {
 	case errors.Is(err, api.ErrNotFound):
 		// not found but ok
@@ -81,14 +134,21 @@ func (d *recordsDataSource) Read(ctx context.Context, req datasource.ReadRequest
 	default:
 		for _, record := range records {
 			state.Records = append(state.Records, recordsModel{
-				ID:   types.StringValue(record.Name),
-				Name: types.StringValue(record.Name),
-				To:   types.StringValue(record.To),
+				ID:         recordID(record.Namespace, record.Name),
+				Namespace:  types.StringValue(record.Namespace),
+				Name:       types.StringValue(record.Name),
+				To:         types.StringValue(record.To),
+				StatusCode: types.Int64Value(int64(record.RedirectStatusCode())),
+				Match:      types.StringValue(string(record.MatchMode())),
+				Query:      types.StringValue(string(record.QueryPolicy())),
+				NotBefore:  timeValue(types.StringNull(), record.NotBefore),
+				ExpiresAt:  timeValue(types.StringNull(), record.ExpiresAt),
+				Version:    types.Int64Value(record.Version),
 			})
 		}
 	}
 
-	diags := resp.State.Set(ctx, &state)
+	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
@@ -21,7 +21,7 @@ func TestAccRecordsDataSource(t *testing.T) {
 			{
 				Config: providerConfig + `resource "redirect-store_record" "test1" {
   name = "test1-name"
-  to = "test1-to"
+  to = "https://example.com/test1"
 }`,
 			},
 			{
@@ -29,7 +29,37 @@ func TestAccRecordsDataSource(t *testing.T) {
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("data.redirect-store_records.test2", "records.#", "1"),
 					resource.TestCheckResourceAttr("data.redirect-store_records.test2", "records.0.name", "test1-name"),
-					resource.TestCheckResourceAttr("data.redirect-store_records.test2", "records.0.to", "test1-to"),
+					resource.TestCheckResourceAttr("data.redirect-store_records.test2", "records.0.to", "https://example.com/test1"),
+					resource.TestCheckResourceAttr("data.redirect-store_records.test2", "records.0.status_code", "301"),
+					resource.TestCheckResourceAttr("data.redirect-store_records.test2", "records.0.match", "exact"),
+				),
+			},
+			// Filter records
+			{
+				Config: providerConfig + `resource "redirect-store_record" "test1" {
+  name = "test1-name"
+  to = "https://example.com/test1"
+}
+
+resource "redirect-store_record" "test3" {
+  name = "test3-name"
+  to = "https://example.com/test3"
+}
+
+data "redirect-store_records" "test3" {
+  name_prefix = "test3"
+  depends_on  = [redirect-store_record.test1, redirect-store_record.test3]
+}
+
+data "redirect-store_records" "test4" {
+  to_contains = "https://example.com/1"
+  depends_on  = [redirect-store_record.test1, redirect-store_record.test3]
+}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.redirect-store_records.test3", "records.#", "1"),
+					resource.TestCheckResourceAttr("data.redirect-store_records.test3", "records.0.name", "test3-name"),
+					resource.TestCheckResourceAttr("data.redirect-store_records.test4", "records.#", "1"),
+					resource.TestCheckResourceAttr("data.redirect-store_records.test4", "records.0.name", "test1-name"),
 				),
 			},
 		},
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"experimental-terraform-redirect-store/api"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+var (
+	_ validator.String = rfc3339Validator{}
+	_ validator.String = namespaceValidator{}
+	_ validator.String = nameValidator{}
+	_ validator.String = toValidator{}
+)
+
+// rfc3339Validator validates that a string is a RFC3339 timestamp.
+type rfc3339Validator struct{}
+
+func (v rfc3339Validator) Description(_ context.Context) string {
+	return "value must be a RFC3339 timestamp"
+}
+
+func (v rfc3339Validator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v rfc3339Validator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid RFC3339 Timestamp",
+			"Could not parse "+req.ConfigValue.ValueString()+": "+err.Error(),
+		)
+	}
+}
+
+// namespaceValidator validates that a string is a namespace of records.
+type namespaceValidator struct{}
+
+func (v namespaceValidator) Description(_ context.Context) string {
+	return "value must consist of lower case alphanumerics and inner hyphens, at most 63 characters"
+}
+
+func (v namespaceValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v namespaceValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if err := api.ValidateNamespace(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Namespace",
+			err.Error(),
+		)
+	}
+}
+
+// nameValidator validates that a string is a name of a record.
+type nameValidator struct{}
+
+func (v nameValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must consist of alphanumerics and -._~/{}, not starting with a slash, at most %d characters", api.MaxNameLength)
+}
+
+func (v nameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v nameValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if err := api.ValidateName(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Name",
+			err.Error(),
+		)
+	}
+}
+
+// toValidator validates that a string is a redirect-to of a record.
+type toValidator struct{}
+
+func (v toValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must be an absolute http or https URL, at most %d characters", api.MaxToLength)
+}
+
+func (v toValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v toValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if err := api.ValidateTo(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid To",
+			err.Error(),
+		)
+	}
+}
+
+// parseTime parses the RFC3339 timestamp, nil if empty.
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}